@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	logs := &bytes.Buffer{}
+	router := NewApiRouter(NewMyApi(), NewOtherApi())
+	router.Config = NewApiConfig()
+	router.Config.AccessLogger = slog.New(slog.NewJSONHandler(logs, nil))
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/my"+ApiUserCreate,
+		strings.NewReader("login=mr.moderator&age=32&status=moderator&full_name=Ivan_Ivanov"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Auth", "100500")
+	req.Header.Set("X-Request-ID", "req-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.Header.Get("X-Request-ID") != "req-1" {
+		t.Errorf("request id is not echoed: %q", resp.Header.Get("X-Request-ID"))
+	}
+
+	resp, err = http.Get(ts.URL + "/my" + ApiUserProfile + "?login=bad_user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got:\n%s", logs)
+	}
+
+	var record map[string]any
+	json.Unmarshal([]byte(lines[0]), &record)
+	expected := map[string]any{
+		"level":      "INFO",
+		"msg":        "MyApi.Create",
+		"method":     http.MethodPost,
+		"route":      ApiUserCreate,
+		"path":       "/my" + ApiUserCreate,
+		"status":     float64(http.StatusOK),
+		"request_id": "req-1",
+		"principal":  "100500",
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, record[key])
+		}
+	}
+	if params, _ := record["params"].(map[string]any); params["login"] != "mr.moderator" || params["age"] != float64(32) {
+		t.Errorf("unexpected params %v", record["params"])
+	}
+
+	json.Unmarshal([]byte(lines[1]), &record)
+	if record["level"] != "ERROR" || record["status"] != float64(http.StatusInternalServerError) || len(record["request_id"].(string)) != 32 {
+		t.Errorf("unexpected record of failed request %s", lines[1])
+	}
+}
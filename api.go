@@ -118,6 +118,27 @@ func (srv *MyApi) Create(ctx context.Context, in CreateParams) (*NewUser, error)
 	return &NewUser{id}, nil
 }
 
+// Signup - тот же Create, но с лимитом запросов, ограничением тела и повтором по Idempotency-Key,
+// чтобы показать эти аннотации, не меняя метод из задания
+
+// apigen:api {"url": "/user/signup", "auth": true, "method": "POST", "ratelimit": {"rate": 10, "burst": 20, "key": "ip"}, "max_body": "1MB", "consumes": ["application/x-www-form-urlencoded"], "idempotent": true}
+func (srv *MyApi) Signup(ctx context.Context, in CreateParams) (*NewUser, error) {
+	return srv.Create(ctx, in)
+}
+
+// Feed - тот же Profile, но потоком ndjson и с любой схемой авторизации,
+// без таких методов генератор не кладет в api_handlers.go код стримов и схем
+
+// apigen:api {"url": "/user/feed", "auth": ["jwt", "basic", "apikey", "cookie", "token"], "stream": "ndjson"}
+func (srv *MyApi) Feed(ctx context.Context, in ProfileParams, send func(*User) error) error {
+	user, err := srv.Profile(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	return send(user)
+}
+
 // 2-я часть
 // это похожая структура, с теми же методами, но у них другие параметры!
 // код, созданный вашим кодогенератором работает с конкретной струткурой, про другие ничего не знает
@@ -2,117 +2,2446 @@
 package main
 
 import (
+	"bytes"
+	"container/list"
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"log/slog"
+	"math"
+	"mime"
+	"net"
 	"net/http"
+	"reflect"
+	"runtime/debug"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
+
+// ApiConfig holds runtime dependencies of the generated handlers
+type ApiConfig struct {
+	// Authenticator checks requests to endpoints with "auth": true
+	Authenticator Authenticator
+	// Authenticators check requests to endpoints with "auth": "<scheme>" or
+	// a list of schemes, e.g. "jwt", "basic", "apikey", "cookie"
+	Authenticators map[string]Authenticator
+	// ErrorLogger gets errors which are answered with 500 "internal error"
+	ErrorLogger func(r *http.Request, err error)
+	// PanicHook gets panics recovered in handlers with their stack
+	PanicHook func(r *http.Request, value any, stack []byte)
+	// Middlewares wrap endpoints with "middleware": [...] by name, functions
+	// marked with apigen:middleware are registered by NewApiConfig
+	Middlewares map[string]func(http.Handler) http.Handler
+	// Metrics records every request when set, e.g. NewPrometheusMetrics()
+	Metrics MetricsRecorder
+	// AccessLogger writes a record per request when set, params fields with
+	// the apivalidator "sensitive" option are redacted
+	AccessLogger *slog.Logger
+	// Tracer gets a span per request with "auth", "validate", "call" and
+	// "encode" children, NoopTracer by default
+	Tracer Tracer
+	// RateLimitKeys bounds callers remembered by every "ratelimit" endpoint,
+	// DefaultRateLimitKeys when 0
+	RateLimitKeys int
+	// Idempotency keeps responses of "idempotent" endpoints by Idempotency-Key,
+	// NewApiConfig keeps DefaultIdempotencyKeys of them in memory for
+	// DefaultIdempotencyTTL, keys are not checked when nil
+	Idempotency IdempotencyStore
+
+	errorMappings []apiErrorMapping
+	rateLimiters  sync.Map
+}
+
+type apiErrorMapping struct {
+	// match returns the error from the chain the mapping is declared for
+	match   func(err error) (error, bool)
+	status  int
+	message string
+}
+
+func NewApiConfig() *ApiConfig {
+	cfg := &ApiConfig{
+		Authenticator: TokenAuthenticator{
+			Header: "X-Auth",
+			Tokens: map[string]Principal{
+				"100500": {ID: "100500"},
+			},
+		},
+		Authenticators: map[string]Authenticator{},
+		ErrorLogger: func(r *http.Request, err error) {
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		},
+		PanicHook: func(r *http.Request, value any, stack []byte) {
+			log.Printf("%s %s: panic: %v\n%s", r.Method, r.URL.Path, value, stack)
+		},
+		Middlewares: map[string]func(http.Handler) http.Handler{},
+		Tracer:      NoopTracer{},
+		Idempotency: NewMemoryIdempotencyStore(DefaultIdempotencyTTL),
+	}
+	registerErrorMappings(cfg)
+	registerMiddlewares(cfg)
+
+	return cfg
+}
+
+// DefaultApiConfig is used by receivers served directly, their handlers and
+// routers carry their own Config
+var DefaultApiConfig = NewApiConfig()
+
+// authenticate tries Authenticators of schemes in order, the default one without
+// schemes, missing authenticators reject the request
+func (cfg *ApiConfig) authenticate(r *http.Request, schemes ...string) (context.Context, error) {
+	if len(schemes) == 0 {
+		if cfg.Authenticator == nil {
+			return nil, errUnauthorized
+		}
+		return cfg.Authenticator.Authenticate(r)
+	}
+
+	err := errUnauthorized
+	for _, scheme := range schemes {
+		authenticator, ok := cfg.Authenticators[scheme]
+		if scheme == "token" {
+			authenticator, ok = cfg.Authenticator, cfg.Authenticator != nil
+		}
+		if !ok || authenticator == nil {
+			continue
+		}
+
+		var ctx context.Context
+		ctx, err = authenticator.Authenticate(r)
+		if err == nil {
+			return ctx, nil
+		}
+	}
+
+	return nil, err
+}
+
+// errorResponse turns an error of the api method into status and public message,
+// anything but ApiError, mapped errors and expired or canceled contexts is
+// hidden from the client
+func (cfg *ApiConfig) errorResponse(r *http.Request, err error) (int, string) {
+	var apiError ApiError
+	if errors.As(err, &apiError) {
+		return apiError.HTTPStatus, apiError.Error()
+	}
+
+	var apiErrorPtr *ApiError
+	if errors.As(err, &apiErrorPtr) && apiErrorPtr != nil {
+		return apiErrorPtr.HTTPStatus, apiErrorPtr.Error()
+	}
+
+	for _, mapping := range cfg.errorMappings {
+		matched, ok := mapping.match(err)
+		if !ok {
+			continue
+		}
+		if mapping.message == "" {
+			return mapping.status, matched.Error()
+		}
+
+		return mapping.status, mapping.message
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable, "request canceled"
+	}
+
+	if cfg.ErrorLogger != nil {
+		cfg.ErrorLogger(r, err)
+	}
+
+	return http.StatusInternalServerError, "internal error"
+}
+
+// recoverPanic answers 500 when the handler or the api method panics,
+// it must be deferred directly
+func (cfg *ApiConfig) recoverPanic(w http.ResponseWriter, r *http.Request) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	if value == http.ErrAbortHandler {
+		panic(value)
+	}
+
+	if cfg.PanicHook != nil {
+		cfg.PanicHook(r, value, debug.Stack())
+	}
+
+	writeError(w, r, http.StatusInternalServerError, "internal error")
+}
+
+// RegisterErrorMapping answers errors matching target with errors.Is,
+// an empty message exposes the text of the matched error
+func (cfg *ApiConfig) RegisterErrorMapping(target error, status int, message string) {
+	cfg.errorMappings = append(cfg.errorMappings, apiErrorMapping{
+		match: func(err error) (error, bool) {
+			return target, errors.Is(err, target)
+		},
+		status:  status,
+		message: message,
+	})
+}
+
+// wrap applies named Middlewares to handler, the first name is the outermost
+func (cfg *ApiConfig) wrap(handler http.HandlerFunc, names ...string) http.Handler {
+	var next http.Handler = handler
+	for i := len(names) - 1; i >= 0; i-- {
+		middleware, ok := cfg.Middlewares[names[i]]
+		if !ok {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if cfg.ErrorLogger != nil {
+					cfg.ErrorLogger(r, fmt.Errorf("middleware %q is not registered", names[i]))
+				}
+				writeError(w, r, http.StatusInternalServerError, "internal error")
+			})
+		}
+		next = middleware(next)
+	}
+
+	return next
+}
+
+// RegisterErrorTypeMapping answers errors of type T found with errors.As
+func RegisterErrorTypeMapping[T error](cfg *ApiConfig, status int, message string) {
+	cfg.errorMappings = append(cfg.errorMappings, apiErrorMapping{
+		match: func(err error) (error, bool) {
+			var target T
+			ok := errors.As(err, &target)
+			return target, ok
+		},
+		status:  status,
+		message: message,
+	})
+}
+
+// bind adapts a generated handler to http.HandlerFunc
+func (cfg *ApiConfig) bind(handler func(http.ResponseWriter, *http.Request, *ApiConfig)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, cfg)
+	}
+}
+
+// Principal is the authenticated caller, api methods get it via PrincipalFromContext
+type Principal struct {
+	ID    string
+	Roles []string
+}
+
+type principalCtxKey struct{}
+
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalCtxKey{}).(Principal)
+	return principal, ok
+}
+
+var errUnauthorized = errors.New("unauthorized")
+
+// hasAnyRole reports whether the authenticated principal has one of roles
+func hasAnyRole(ctx context.Context, roles ...string) bool {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	for _, role := range principal.Roles {
+		if slices.Contains(roles, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authenticator checks the request and returns its context with the Principal attached
+type Authenticator interface {
+	Authenticate(r *http.Request) (context.Context, error)
+}
+
+// AuthenticatorFunc adapts a function to Authenticator
+type AuthenticatorFunc func(r *http.Request) (context.Context, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (context.Context, error) {
+	return f(r)
+}
+
+// TokenAuthenticator accepts requests carrying one of the known Tokens in Header
+type TokenAuthenticator struct {
+	Header string
+	Tokens map[string]Principal
+}
+
+func (a TokenAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	token := r.Header.Get(a.Header)
+	principal, ok := a.Tokens[token]
+	if token == "" || !ok {
+		return nil, errUnauthorized
+	}
+
+	return ContextWithPrincipal(r.Context(), principal), nil
+}
+
+var (
+	errJWTNoSecret  = errors.New("token secret is not set")
+	errJWTMalformed = errors.New("malformed token")
+	errJWTAlgorithm = errors.New("unsupported token algorithm")
+	errJWTSignature = errors.New("bad token signature")
+	errJWTExpired   = errors.New("token expired")
+	errJWTNotBefore = errors.New("token not valid yet")
+	errJWTAudience  = errors.New("token audience mismatch")
+)
+
+type jwtClaimsCtxKey struct{}
+
+// ClaimsFromContext returns claims of the verified JWT
+func ClaimsFromContext(ctx context.Context) (map[string]any, bool) {
+	claims, ok := ctx.Value(jwtClaimsCtxKey{}).(map[string]any)
+	return claims, ok
+}
+
+// JWTAuthenticator checks "Authorization: Bearer <token>" signed with HS256 or HS512.
+// Principal.ID is taken from "sub" and Principal.Roles from "roles"
+type JWTAuthenticator struct {
+	Secret []byte
+	// Audience is required in "aud" when not empty
+	Audience string
+	// Leeway is allowed clock skew for "exp" and "nbf"
+	Leeway time.Duration
+	Now    func() time.Time
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return nil, errUnauthorized
+	}
+
+	claims, err := a.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := Principal{}
+	principal.ID, _ = claims["sub"].(string)
+	roles, _ := claims["roles"].([]any)
+	for _, role := range roles {
+		if role, ok := role.(string); ok {
+			principal.Roles = append(principal.Roles, role)
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), jwtClaimsCtxKey{}, claims)
+	return ContextWithPrincipal(ctx, principal), nil
+}
+
+// Verify checks the signature and the registered time and audience claims,
+// every token is rejected without Secret
+func (a *JWTAuthenticator) Verify(token string) (map[string]any, error) {
+	if len(a.Secret) == 0 {
+		return nil, errJWTNoSecret
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errJWTMalformed
+	}
+
+	header := struct {
+		Alg string `json:"alg"`
+	}{}
+	if err := jwtDecodePart(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	var newHash func() hash.Hash
+	switch header.Alg {
+	case "HS256":
+		newHash = sha256.New
+	case "HS512":
+		newHash = sha512.New
+	default:
+		return nil, errJWTAlgorithm
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errJWTMalformed
+	}
+
+	mac := hmac.New(newHash, a.Secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errJWTSignature
+	}
+
+	claims := map[string]any{}
+	if err := jwtDecodePart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if a.Now != nil {
+		now = a.Now()
+	}
+
+	exp, ok, err := jwtTime(claims, "exp")
+	if err != nil {
+		return nil, err
+	}
+	if ok && !now.Before(exp.Add(a.Leeway)) {
+		return nil, errJWTExpired
+	}
+
+	nbf, ok, err := jwtTime(claims, "nbf")
+	if err != nil {
+		return nil, err
+	}
+	if ok && now.Add(a.Leeway).Before(nbf) {
+		return nil, errJWTNotBefore
+	}
+
+	if a.Audience != "" && !jwtHasAudience(claims["aud"], a.Audience) {
+		return nil, errJWTAudience
+	}
+
+	return claims, nil
+}
+
+func jwtDecodePart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errJWTMalformed
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return errJWTMalformed
+	}
+
+	return nil
+}
+
+// jwtTime reads the NumericDate claim name, it is not ok when the claim is
+// absent and malformed when it is anything but a number of seconds
+func jwtTime(claims map[string]any, name string) (time.Time, bool, error) {
+	claim, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	number, ok := claim.(json.Number)
+	if !ok {
+		return time.Time{}, false, errJWTMalformed
+	}
+
+	seconds, err := number.Float64()
+	if err != nil || math.Abs(seconds) > 1<<62 {
+		return time.Time{}, false, errJWTMalformed
+	}
+
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))), true, nil
+}
+
+func jwtHasAudience(claim any, audience string) bool {
+	switch aud := claim.(type) {
+	case string:
+		return aud == audience
+	case []any:
+		for _, item := range aud {
+			if item == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// BasicAuthenticator checks "Authorization: Basic" credentials
+type BasicAuthenticator struct {
+	Verify func(ctx context.Context, user, password string) (Principal, error)
+}
+
+func (a BasicAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	user, password, ok := r.BasicAuth()
+	if !ok || a.Verify == nil {
+		return nil, errUnauthorized
+	}
+
+	principal, err := a.Verify(r.Context(), user, password)
+	return verifiedContext(r, principal, err)
+}
+
+// APIKeyAuthenticator takes the key from Header, then from Query parameter
+type APIKeyAuthenticator struct {
+	Header string
+	Query  string
+	Verify func(ctx context.Context, key string) (Principal, error)
+}
+
+func (a APIKeyAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	key := ""
+	if a.Header != "" {
+		key = r.Header.Get(a.Header)
+	}
+	if key == "" && a.Query != "" {
+		key = r.URL.Query().Get(a.Query)
+	}
+	if key == "" || a.Verify == nil {
+		return nil, errUnauthorized
+	}
+
+	principal, err := a.Verify(r.Context(), key)
+	return verifiedContext(r, principal, err)
+}
+
+// CookieAuthenticator checks the session stored in the cookie Name
+type CookieAuthenticator struct {
+	Name   string
+	Verify func(ctx context.Context, session string) (Principal, error)
+}
+
+func (a CookieAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	cookie, err := r.Cookie(a.Name)
+	if err != nil || cookie.Value == "" || a.Verify == nil {
+		return nil, errUnauthorized
+	}
+
+	principal, err := a.Verify(r.Context(), cookie.Value)
+	return verifiedContext(r, principal, err)
+}
+
+func verifiedContext(r *http.Request, principal Principal, err error) (context.Context, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return ContextWithPrincipal(r.Context(), principal), nil
+}
+
 type response struct {
-	Response json.RawMessage `json:"response"`
-	Error    string          `json:"error"`
+	XMLName  xml.Name `json:"-" xml:"response"`
+	Response any      `json:"response,omitempty" xml:"response,omitempty"`
+	Error    string   `json:"error" xml:"error"`
+}
+
+var jsonContentType = []string{jsonMediaType}
+
+type responseBuffer struct {
+	bytes.Buffer
+	encoder *json.Encoder
+}
+
+// MaxPooledResponseSize is the largest response buffer kept for reuse, it
+// fits lists of ten thousand users, larger buffers are left to the GC
+var MaxPooledResponseSize = 1 << 20
+
+var responseBufferPool = sync.Pool{
+	New: func() any {
+		buf := &responseBuffer{}
+		buf.encoder = json.NewEncoder(&buf.Buffer)
+		return buf
+	},
+}
+
+func putResponseBuffer(buf *responseBuffer) {
+	if buf.Cap() <= MaxPooledResponseSize {
+		buf.Reset()
+		responseBufferPool.Put(buf)
+	}
+}
+
+// writeResponse encodes body in a single pass into a pooled buffer, so encoding
+// errors can still be answered with 500 before anything is written. The format
+// follows Content-Type set by negotiate, JSON bodies with generated encoders
+// skip encoding/json reflection
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, body any) {
+	buf := responseBufferPool.Get().(*responseBuffer)
+	defer putResponseBuffer(buf)
+
+	header := w.Header()
+	mediaType := mediaTypeOf(header.Get("Content-Type"))
+
+	var err error
+	switch _, isAppender := body.(jsonAppender); {
+	case !isJSONMediaType(mediaType):
+		encoder := lookupEncoder(mediaType)
+		if encoder == nil {
+			err = fmt.Errorf("no encoder for %s", mediaType)
+			break
+		}
+		err = encoder.Encode(&buf.Buffer, body)
+	case isAppender:
+		var data []byte
+		data, err = appendJSONValue(buf.AvailableBuffer(), body)
+		buf.Write(append(data, '\n'))
+	default:
+		err = buf.encoder.Encode(body)
+	}
+
+	if err != nil {
+		if status == http.StatusInternalServerError {
+			w.WriteHeader(status)
+			return
+		}
+
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if mediaType == "" {
+		header["Content-Type"] = jsonContentType
+	}
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// jsonAppender is implemented by result types with generated encoders
+type jsonAppender interface {
+	AppendJSON(dst []byte) ([]byte, error)
+}
+
+const jsonHex = "0123456789abcdef"
+
+// appendJSONString appends s as a JSON string with HTML characters escaped
+// and invalid UTF-8 replaced, as encoding/json does
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', jsonHex[b>>4], jsonHex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i += size
+			start = i
+			continue
+		}
+
+		if c == '\u2028' || c == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', jsonHex[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+
+		i += size
+	}
+
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// appendJSONFloat formats f like encoding/json: exponent only for very
+// small or large values and an error for NaN and infinities
+func appendJSONFloat(dst []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return dst, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	dst = strconv.AppendFloat(dst, f, format, -1, bits)
+	if format == 'e' {
+		// e-09 to e-9
+		if n := len(dst); n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+
+	return dst, nil
+}
+
+// appendJSONValue uses the generated encoder when v has one, slices, arrays
+// and pointers of such types are walked here, the rest goes to the pooled
+// encoding/json encoder
+func appendJSONValue(dst []byte, v any) ([]byte, error) {
+	if appender, ok := v.(jsonAppender); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return append(dst, "null"...), nil
+		}
+		return appender.AppendJSON(dst)
+	}
+
+	if rv := reflect.ValueOf(v); rv.IsValid() && jsonAppendable(rv.Type()) {
+		return appendJSONReflect(dst, rv)
+	}
+
+	buf := responseBufferPool.Get().(*responseBuffer)
+	defer putResponseBuffer(buf)
+	if err := buf.encoder.Encode(v); err != nil {
+		return dst, err
+	}
+
+	// Encode ends the value with a newline
+	return append(dst, buf.Bytes()[:buf.Len()-1]...), nil
+}
+
+var (
+	jsonAppenderType  = reflect.TypeFor[jsonAppender]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// jsonAppendable reports whether t has a generated encoder or is a slice,
+// array or pointer leading to one, types encoding themselves are left to
+// encoding/json. Recursive types like type L []L give up after a few levels
+func jsonAppendable(t reflect.Type) bool {
+	for depth := 0; depth < 8; depth++ {
+		switch {
+		case t.Implements(jsonAppenderType):
+			return true
+		case t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType):
+			return false
+		case t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+			t = t.Elem()
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// appendJSONReflect encodes a value of a jsonAppendable type, addressable
+// elements are passed by pointer so they aren't boxed one by one
+func appendJSONReflect(dst []byte, rv reflect.Value) ([]byte, error) {
+	switch {
+	case rv.Kind() == reflect.Pointer && rv.IsNil(), rv.Kind() == reflect.Slice && rv.IsNil():
+		return append(dst, "null"...), nil
+	case rv.Type().Implements(jsonAppenderType):
+		if rv.Kind() != reflect.Pointer && rv.CanAddr() {
+			rv = rv.Addr()
+		}
+		return rv.Interface().(jsonAppender).AppendJSON(dst)
+	case rv.Kind() == reflect.Pointer:
+		return appendJSONReflect(dst, rv.Elem())
+	}
+
+	var err error
+	dst = append(dst, '[')
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst, err = appendJSONReflect(dst, rv.Index(i))
+		if err != nil {
+			return dst, err
+		}
+	}
+
+	return append(dst, ']'), nil
+}
+
+func (resp response) AppendJSON(dst []byte) ([]byte, error) {
+	dst = append(dst, '{')
+	if resp.Response != nil {
+		var err error
+		dst = append(dst, "\"response\":"...)
+		dst, err = appendJSONValue(dst, resp.Response)
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, ',')
+	}
+
+	dst = append(dst, "\"error\":"...)
+	dst = appendJSONString(dst, resp.Error)
+	return append(dst, '}'), nil
+}
+
+const (
+	jsonMediaType = "application/json"
+	xmlMediaType  = "application/xml"
+)
+
+// Encoder writes response bodies of one media type
+type Encoder interface {
+	Encode(w io.Writer, v any) error
+}
+
+// EncoderFunc adapts a function to Encoder
+type EncoderFunc func(w io.Writer, v any) error
+
+func (f EncoderFunc) Encode(w io.Writer, v any) error {
+	return f(w, v)
+}
+
+// XMLEncoder writes the XML declaration and v with encoding/xml
+var XMLEncoder = EncoderFunc(func(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	return xml.NewEncoder(w).Encode(v)
+})
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{
+		xmlMediaType: XMLEncoder,
+		"text/xml":   XMLEncoder,
+	}
+	// encoderMediaTypes are offered in this order when Accept weights are equal
+	encoderMediaTypes = []string{jsonMediaType, xmlMediaType, "text/xml"}
+)
+
+// RegisterEncoder makes responses available in mediaType, e.g. a binary
+// format for "application/x-msgpack". application/json can't be replaced
+func RegisterEncoder(mediaType string, encoder Encoder) {
+	mediaType = strings.ToLower(mediaType)
+	if mediaType == jsonMediaType {
+		panic("apigen: application/json encoder is built in")
+	}
+
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	if _, ok := encoders[mediaType]; !ok {
+		encoderMediaTypes = append(encoderMediaTypes, mediaType)
+	}
+	encoders[mediaType] = encoder
+}
+
+// lookupEncoder returns the encoder of mediaType, structured syntax suffixes
+// like application/problem+xml use the encoder of their suffix
+func lookupEncoder(mediaType string) Encoder {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	if encoder, ok := encoders[mediaType]; ok {
+		return encoder
+	}
+	if strings.HasSuffix(mediaType, "+xml") {
+		return encoders[xmlMediaType]
+	}
+
+	return nil
+}
+
+// mediaTypeOf returns the lower case media type of a Content-Type without params
+func mediaTypeOf(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "" || mediaType == jsonMediaType || strings.HasSuffix(mediaType, "+json")
+}
+
+var varyAccept = []string{"Accept"}
+
+// negotiate stores the media type chosen by Accept in Content-Type for
+// writeResponse and writeError. It reports false when nothing offered is
+// acceptable, Content-Type stays JSON for the 406 the handler answers after
+// its method and auth checks
+func negotiate(w http.ResponseWriter, r *http.Request) bool {
+	header := w.Header()
+	if _, ok := header["Vary"]; !ok {
+		header["Vary"] = varyAccept
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return true
+	}
+
+	encodersMu.RLock()
+	mediaType := negotiateMediaType(accept, encoderMediaTypes)
+	encodersMu.RUnlock()
+
+	switch mediaType {
+	case "":
+		return false
+	case jsonMediaType:
+		return true
+	}
+
+	header.Set("Content-Type", mediaType)
+	return true
+}
+
+// negotiateMediaType returns the offered media type with the highest
+// quality in accept, the most specific media range sets the quality
+func negotiateMediaType(accept string, offered []string) string {
+	best, bestQuality := "", 0.0
+	for _, offer := range offered {
+		quality, specificity := 0.0, -1
+		for _, mediaRange := range strings.Split(accept, ",") {
+			rangeType, params, _ := strings.Cut(mediaRange, ";")
+			rangeType = strings.ToLower(strings.TrimSpace(rangeType))
+
+			rangeSpecificity := 0
+			switch {
+			case rangeType == offer:
+				rangeSpecificity = 2
+			case strings.HasSuffix(rangeType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(rangeType, "*")):
+				rangeSpecificity = 1
+			case rangeType == "*/*" || rangeType == "*":
+			default:
+				continue
+			}
+			if rangeSpecificity <= specificity {
+				continue
+			}
+
+			specificity, quality = rangeSpecificity, 1
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(param, "=")
+				if strings.TrimSpace(name) != "q" {
+					continue
+				}
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+
+	return best
+}
+
+// apiStream writes items as NDJSON lines or server-sent events and flushes
+// after each one, headers are sent with the first item
+type apiStream struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	sse        bool
+	started    bool
+	buf        []byte
+}
+
+func newApiStream(w http.ResponseWriter, sse bool) *apiStream {
+	return &apiStream{w: w, controller: http.NewResponseController(w), sse: sse}
+}
+
+func (s *apiStream) start() {
+	header := s.w.Header()
+	if s.sse {
+		header.Set("Content-Type", "text/event-stream")
+	} else {
+		header.Set("Content-Type", "application/x-ndjson")
+	}
+	header.Set("Cache-Control", "no-cache")
+	s.w.WriteHeader(http.StatusOK)
+	s.started = true
+}
+
+func (s *apiStream) send(item any) error {
+	if !s.started {
+		s.start()
+	}
+
+	s.buf = s.buf[:0]
+	if s.sse {
+		s.buf = append(s.buf, "data: "...)
+	}
+
+	var err error
+	s.buf, err = appendJSONValue(s.buf, item)
+	if err != nil {
+		return err
+	}
+
+	return s.write()
+}
+
+func (s *apiStream) write() error {
+	if s.sse {
+		s.buf = append(s.buf, '\n', '\n')
+	} else {
+		s.buf = append(s.buf, '\n')
+	}
+
+	if _, err := s.w.Write(s.buf); err != nil {
+		return err
+	}
+	if err := s.controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	return nil
+}
+
+// fail answers with the regular error response before the first item and
+// with a last {"error": ...} line or an "error" event after it
+func (s *apiStream) fail(r *http.Request, status int, message string) {
+	if !s.started {
+		writeError(s.w, r, status, message)
+		return
+	}
+
+	s.buf = s.buf[:0]
+	if s.sse {
+		s.buf = append(s.buf, "event: error\ndata: "...)
+	}
+	s.buf, _ = response{Error: message}.AppendJSON(s.buf)
+	s.write()
+}
+
+// close sends headers of a stream without items
+func (s *apiStream) close() {
+	if !s.started {
+		s.start()
+	}
+}
+
+// StatusCoder lets a result choose the success status at runtime
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// Headerer lets a result add response headers, e.g. Location of a created user
+type Headerer interface {
+	Headers() http.Header
+}
+
+// writeResult applies StatusCoder and Headerer of the result and writes body,
+// 204 and 304 are sent without it
+func writeResult(w http.ResponseWriter, r *http.Request, status int, result, body any) {
+	if rv := reflect.ValueOf(result); rv.Kind() != reflect.Pointer || !rv.IsNil() {
+		if coder, ok := result.(StatusCoder); ok {
+			if code := coder.StatusCode(); code != 0 {
+				status = code
+			}
+		}
+
+		if headerer, ok := result.(Headerer); ok {
+			header := w.Header()
+			for key, values := range headerer.Headers() {
+				for _, value := range values {
+					header.Add(key, value)
+				}
+			}
+		}
+	}
+
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(status)
+		return
+	}
+
+	writeResponse(w, r, status, body)
+}
+
+// MetricsRecorder gets measurements of generated endpoints labeled by
+// receiver type and method name
+type MetricsRecorder interface {
+	// Start is called when the request is accepted by the endpoint
+	Start(receiver, method string)
+	// Done is called with the written status after the handler returns
+	Done(receiver, method string, status int, duration time.Duration)
+}
+
+// apiStatusWriter remembers the status, Flush and Unwrap keep streaming and
+// http.ResponseController working through it
+type apiStatusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *apiStatusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *apiStatusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *apiStatusWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *apiStatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *apiStatusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func apiObserveNothing() {}
+
+// observe starts measuring, logging and tracing the request, the returned
+// func must be deferred before recoverPanic so 500 of recovered panics is seen
+func (cfg *ApiConfig) observe(w http.ResponseWriter, r *http.Request, endpoint *ApiEndpoint) (http.ResponseWriter, *http.Request, func()) {
+	metrics, logger, tracer := cfg.Metrics, cfg.AccessLogger, cfg.tracer()
+	if metrics == nil && logger == nil && tracer == nil {
+		return w, r, apiObserveNothing
+	}
+
+	start := time.Now()
+	sw := &apiStatusWriter{ResponseWriter: w}
+	ctx := r.Context()
+
+	var info *apiRequestInfo
+	if logger != nil {
+		info = &apiRequestInfo{requestID: r.Header.Get("X-Request-ID")}
+		if info.requestID == "" || len(info.requestID) > 128 {
+			info.requestID = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", info.requestID)
+		ctx = context.WithValue(ctx, apiRequestInfoCtxKey{}, info)
+	}
+
+	var span Span
+	if tracer != nil {
+		ctx, span = tracer.Start(ctx, endpoint.Receiver+"."+endpoint.Method, endpoint.spanAttrs()...)
+	}
+
+	if ctx != r.Context() {
+		r = r.WithContext(ctx)
+	}
+
+	if metrics != nil {
+		metrics.Start(endpoint.Receiver, endpoint.Method)
+	}
+
+	return sw, r, func() {
+		latency := time.Since(start)
+		if metrics != nil {
+			metrics.Done(endpoint.Receiver, endpoint.Method, sw.Status(), latency)
+		}
+		if logger != nil {
+			cfg.logAccess(r, endpoint, info, sw.Status(), latency)
+		}
+		if span != nil {
+			var err error
+			if sw.Status() >= http.StatusInternalServerError {
+				err = errors.New(http.StatusText(sw.Status()))
+			}
+			span.SetAttributes(SpanAttr{"http.status_code", sw.Status()})
+			span.End(err)
+		}
+	}
+}
+
+// DefaultLatencyBuckets are upper bounds of the latency histogram in seconds
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type apiEndpointKey struct {
+	receiver string
+	method   string
+}
+
+type apiEndpointMetrics struct {
+	mu       sync.Mutex
+	inFlight int64
+	statuses map[int]uint64
+	buckets  []uint64
+	count    uint64
+	sum      float64
+}
+
+// PrometheusMetrics counts requests by status, observes latency and in-flight
+// requests of every endpoint and serves them in the Prometheus text format
+type PrometheusMetrics struct {
+	// Buckets must not be changed after the first request
+	Buckets []float64
+
+	endpoints sync.Map
+}
+
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{Buckets: DefaultLatencyBuckets}
+}
+
+func (m *PrometheusMetrics) endpoint(receiver, method string) *apiEndpointMetrics {
+	key := apiEndpointKey{receiver, method}
+	if endpoint, ok := m.endpoints.Load(key); ok {
+		return endpoint.(*apiEndpointMetrics)
+	}
+
+	endpoint, _ := m.endpoints.LoadOrStore(key, &apiEndpointMetrics{
+		statuses: map[int]uint64{},
+		buckets:  make([]uint64, len(m.Buckets)),
+	})
+	return endpoint.(*apiEndpointMetrics)
+}
+
+func (m *PrometheusMetrics) Start(receiver, method string) {
+	endpoint := m.endpoint(receiver, method)
+	endpoint.mu.Lock()
+	endpoint.inFlight++
+	endpoint.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) Done(receiver, method string, status int, duration time.Duration) {
+	seconds := duration.Seconds()
+	endpoint := m.endpoint(receiver, method)
+
+	endpoint.mu.Lock()
+	defer endpoint.mu.Unlock()
+
+	endpoint.inFlight--
+	endpoint.statuses[status]++
+	endpoint.count++
+	endpoint.sum += seconds
+	for i, bound := range m.Buckets {
+		if seconds <= bound {
+			endpoint.buckets[i]++
+		}
+	}
+}
+
+// ServeHTTP writes metrics in the Prometheus text exposition format
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	keys := make([]apiEndpointKey, 0)
+	m.endpoints.Range(func(key, _ any) bool {
+		keys = append(keys, key.(apiEndpointKey))
+		return true
+	})
+	slices.SortFunc(keys, func(a, b apiEndpointKey) int {
+		return strings.Compare(a.receiver+"."+a.method, b.receiver+"."+b.method)
+	})
+
+	var requests, latency, inFlight bytes.Buffer
+	requests.WriteString("# HELP apigen_requests_total Requests served by generated handlers.\n# TYPE apigen_requests_total counter\n")
+	latency.WriteString("# HELP apigen_request_duration_seconds Latency of generated handlers.\n# TYPE apigen_request_duration_seconds histogram\n")
+	inFlight.WriteString("# HELP apigen_requests_in_flight Requests being served by generated handlers.\n# TYPE apigen_requests_in_flight gauge\n")
+
+	for _, key := range keys {
+		value, _ := m.endpoints.Load(key)
+		endpoint := value.(*apiEndpointMetrics)
+		labels := fmt.Sprintf("receiver=%q,method=%q", key.receiver, key.method)
+
+		endpoint.mu.Lock()
+		statuses := make([]int, 0, len(endpoint.statuses))
+		for status := range endpoint.statuses {
+			statuses = append(statuses, status)
+		}
+		slices.Sort(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(&requests, "apigen_requests_total{%s,status=\"%d\"} %d\n", labels, status, endpoint.statuses[status])
+		}
+
+		for i, bound := range m.Buckets {
+			fmt.Fprintf(&latency, "apigen_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), endpoint.buckets[i])
+		}
+		fmt.Fprintf(&latency, "apigen_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, endpoint.count)
+		fmt.Fprintf(&latency, "apigen_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(endpoint.sum, 'g', -1, 64))
+		fmt.Fprintf(&latency, "apigen_request_duration_seconds_count{%s} %d\n", labels, endpoint.count)
+
+		fmt.Fprintf(&inFlight, "apigen_requests_in_flight{%s} %d\n", labels, endpoint.inFlight)
+		endpoint.mu.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	requests.WriteTo(w)
+	latency.WriteTo(w)
+	inFlight.WriteTo(w)
+}
+
+// ApiEndpoint describes a generated handler
+type ApiEndpoint struct {
+	Receiver   string
+	Method     string
+	HTTPMethod string
+	Route      string
+	Auth       bool
+	Roles      []string
+	Stream     string
+	Timeout    string
+	RateLimit  *ApiRateLimit
+}
+
+type apiRequestInfoCtxKey struct{}
+
+// apiRequestInfo is filled while the request is handled and logged at the end
+type apiRequestInfo struct {
+	requestID string
+	principal string
+	params    any
+}
+
+func requestInfo(r *http.Request) *apiRequestInfo {
+	info, _ := r.Context().Value(apiRequestInfoCtxKey{}).(*apiRequestInfo)
+	return info
+}
+
+// RequestIDFromContext returns X-Request-ID of the request or the generated one
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	info, ok := ctx.Value(apiRequestInfoCtxKey{}).(*apiRequestInfo)
+	if !ok {
+		return "", false
+	}
+	return info.requestID, true
+}
+
+func newRequestID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+func (cfg *ApiConfig) logAccess(r *http.Request, endpoint *ApiEndpoint, info *apiRequestInfo, status int, latency time.Duration) {
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("route", endpoint.Route),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", status),
+		slog.Duration("latency", latency),
+		slog.String("request_id", info.requestID),
+	}
+	if info.principal != "" {
+		attrs = append(attrs, slog.String("principal", info.principal))
+	}
+	if info.params != nil {
+		attrs = append(attrs, slog.Any("params", info.params))
+	}
+
+	cfg.AccessLogger.LogAttrs(r.Context(), level, endpoint.Receiver+"."+endpoint.Method, attrs...)
+}
+
+// Tracer creates spans around generated handlers and their phases, the span
+// of the endpoint carries attributes of its apigen:api annotation
+type Tracer interface {
+	// Start returns ctx with the new span, spans started with it are children
+	Start(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, Span)
+}
+
+// Span is finished once by End, err is nil when the phase succeeded
+type Span interface {
+	SetAttributes(attrs ...SpanAttr)
+	End(err error)
+}
+
+type SpanAttr struct {
+	Key   string
+	Value any
+}
+
+// ErrRequestRejected ends phase spans which answered with an error, e.g. the
+// "validate" span of a request with a missing required param
+var ErrRequestRejected = errors.New("request rejected")
+
+// NoopTracer is the default tracer, generated handlers skip it entirely
+type NoopTracer struct{}
+
+func (NoopTracer) Start(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...SpanAttr) {}
+
+func (noopSpan) End(err error) {}
+
+func (cfg *ApiConfig) tracer() Tracer {
+	if _, noop := cfg.Tracer.(NoopTracer); noop {
+		return nil
+	}
+	return cfg.Tracer
+}
+
+// spanAttrs describe the endpoint by its annotation
+func (e *ApiEndpoint) spanAttrs() []SpanAttr {
+	attrs := []SpanAttr{
+		{"apigen.receiver", e.Receiver},
+		{"apigen.method", e.Method},
+		{"http.route", e.Route},
+		{"apigen.auth", e.Auth},
+	}
+	if e.HTTPMethod != "" {
+		attrs = append(attrs, SpanAttr{"http.method", e.HTTPMethod})
+	}
+	if len(e.Roles) > 0 {
+		attrs = append(attrs, SpanAttr{"apigen.roles", e.Roles})
+	}
+	if e.Stream != "" {
+		attrs = append(attrs, SpanAttr{"apigen.stream", e.Stream})
+	}
+	if e.Timeout != "" {
+		attrs = append(attrs, SpanAttr{"apigen.timeout", e.Timeout})
+	}
+	return attrs
+}
+
+// apiPhase ends its span at most once, so the deferred end only finishes
+// phases left by an early return
+type apiPhase struct {
+	span  Span
+	ended bool
+}
+
+func (p *apiPhase) end(err error) {
+	if p.span == nil || p.ended {
+		return
+	}
+	p.ended = true
+	p.span.End(err)
+}
+
+func (cfg *ApiConfig) startPhase(ctx context.Context, name string) (context.Context, apiPhase) {
+	tracer := cfg.tracer()
+	if tracer == nil {
+		return ctx, apiPhase{}
+	}
+
+	ctx, span := tracer.Start(ctx, name)
+	return ctx, apiPhase{span: span}
+}
+
+// RecordingTracer keeps finished spans in memory, it is meant for tests
+type RecordingTracer struct {
+	mu     sync.Mutex
+	lastID int
+	spans  []RecordedSpan
+}
+
+// RecordedSpan is a finished span, ParentID is 0 for root spans
+type RecordedSpan struct {
+	ID       int
+	ParentID int
+	Name     string
+	Attrs    []SpanAttr
+	Err      error
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Attr returns the value of the last attribute with key
+func (s RecordedSpan) Attr(key string) (any, bool) {
+	for i := len(s.Attrs) - 1; i >= 0; i-- {
+		if s.Attrs[i].Key == key {
+			return s.Attrs[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+type recordingSpanCtxKey struct{}
+
+type recordingSpan struct {
+	tracer *RecordingTracer
+	span   RecordedSpan
+}
+
+func (t *RecordingTracer) Start(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, Span) {
+	t.mu.Lock()
+	t.lastID++
+	id := t.lastID
+	t.mu.Unlock()
+
+	span := &recordingSpan{tracer: t, span: RecordedSpan{
+		ID:    id,
+		Name:  name,
+		Attrs: slices.Clone(attrs),
+		Start: time.Now(),
+	}}
+	if parent, ok := ctx.Value(recordingSpanCtxKey{}).(*recordingSpan); ok {
+		span.span.ParentID = parent.span.ID
+	}
+
+	return context.WithValue(ctx, recordingSpanCtxKey{}, span), span
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...SpanAttr) {
+	s.span.Attrs = append(s.span.Attrs, attrs...)
+}
+
+func (s *recordingSpan) End(err error) {
+	s.span.Err = err
+	s.span.Duration = time.Since(s.span.Start)
+
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, s.span)
+	s.tracer.mu.Unlock()
+}
+
+// Spans returns finished spans in the order they ended
+func (t *RecordingTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.spans)
+}
+
+func (t *RecordingTracer) Reset() {
+	t.mu.Lock()
+	t.spans = nil
+	t.mu.Unlock()
+}
+
+// ApiRateLimit allows Rate requests per second with bursts of Burst requests
+// per key: "ip", "principal" or "header:<name>"
+type ApiRateLimit struct {
+	Rate  float64
+	Burst int
+	Key   string
+}
+
+// DefaultRateLimitKeys bounds the keys remembered per endpoint
+const DefaultRateLimitKeys = 10000
+
+type apiBucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// apiRateLimiter forgets the least recently seen key when full, so an
+// evicted key starts again with a full bucket
+type apiRateLimiter struct {
+	limit   *ApiRateLimit
+	maxKeys int
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	lru     *list.List
+}
+
+func newApiRateLimiter(limit *ApiRateLimit, maxKeys int) *apiRateLimiter {
+	if maxKeys <= 0 {
+		maxKeys = DefaultRateLimitKeys
+	}
+	return &apiRateLimiter{
+		limit:   limit,
+		maxKeys: maxKeys,
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// allow takes a token of key, otherwise returns when the next one is ready
+func (l *apiRateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var bucket *apiBucket
+	if elem, ok := l.buckets[key]; ok {
+		l.lru.MoveToFront(elem)
+		bucket = elem.Value.(*apiBucket)
+		elapsed := now.Sub(bucket.last).Seconds()
+		bucket.tokens = math.Min(float64(l.limit.Burst), bucket.tokens+elapsed*l.limit.Rate)
+		bucket.last = now
+	} else {
+		if l.lru.Len() >= l.maxKeys {
+			oldest := l.lru.Back()
+			l.lru.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*apiBucket).key)
+		}
+		bucket = &apiBucket{key: key, tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = l.lru.PushFront(bucket)
+	}
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.limit.Rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// rateLimitKey identifies the caller, ctx carries the principal of auth,
+// "ip" is the peer address and ignores X-Forwarded-For
+func rateLimitKey(ctx context.Context, r *http.Request, key string) string {
+	switch key {
+	case "ip":
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+		return host
+	case "principal":
+		principal, _ := PrincipalFromContext(ctx)
+		return principal.ID
+	default:
+		return r.Header.Get(strings.TrimPrefix(key, "header:"))
+	}
+}
+
+// allowRequest answers 429 with Retry-After in whole seconds when the caller
+// is out of tokens
+func (cfg *ApiConfig) allowRequest(ctx context.Context, w http.ResponseWriter, r *http.Request, endpoint *ApiEndpoint) bool {
+	limiter, ok := cfg.rateLimiters.Load(endpoint)
+	if !ok {
+		limiter, _ = cfg.rateLimiters.LoadOrStore(endpoint, newApiRateLimiter(endpoint.RateLimit, cfg.RateLimitKeys))
+	}
+
+	allowed, retryAfter := limiter.(*apiRateLimiter).allow(rateLimitKey(ctx, r, endpoint.RateLimit.Key), time.Now())
+	if allowed {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	writeError(w, r, http.StatusTooManyRequests, "too many requests")
+	return false
+}
+
+// checkBody answers 415 when a request with a body is not one of consumes
+// media types and parses the form reading at most maxBody bytes, a larger
+// body is answered with 413
+func checkBody(w http.ResponseWriter, r *http.Request, maxBody int64, consumes ...string) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if len(consumes) > 0 && r.ContentLength != 0 && !slices.Contains(consumes, mediaType) {
+		writeError(w, r, http.StatusUnsupportedMediaType, "unsupported media type")
+		return false
+	}
+
+	if maxBody <= 0 {
+		return true
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+
+	var err error
+	if mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(maxBody)
+	} else {
+		err = r.ParseForm()
+	}
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+		return false
+	case err != nil:
+		writeError(w, r, http.StatusBadRequest, "bad request body")
+		return false
+	}
+
+	return true
+}
+
+// IdempotentResponse is the first response to an Idempotency-Key,
+// Fingerprint identifies the params of the request it answered
+type IdempotentResponse struct {
+	Status      int
+	Header      http.Header
+	Body        []byte
+	Fingerprint string
+}
+
+// ErrIdempotencyInFlight is returned by Begin while the first request with
+// the key is served, the duplicate is answered with 409
+var ErrIdempotencyInFlight = errors.New("request with the idempotency key is in flight")
+
+// IdempotencyStore keeps responses by key, keys are scoped by the endpoint
+// and the principal
+type IdempotencyStore interface {
+	// Begin reserves key, for a finished request it returns its response
+	Begin(ctx context.Context, key string) (*IdempotentResponse, error)
+	// Save stores the response of the reserved key
+	Save(ctx context.Context, key string, resp *IdempotentResponse) error
+	// Release drops the reservation, the next request with key is served again
+	Release(ctx context.Context, key string)
+}
+
+const (
+	// DefaultIdempotencyTTL is how long NewApiConfig replays responses
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// DefaultIdempotencyKeys bounds the keys of MemoryIdempotencyStore
+	DefaultIdempotencyKeys = 10000
+)
+
+// idempotencySweeps is how many times per TTL expired responses are dropped
+const idempotencySweeps = 10
+
+type idempotencyEntry struct {
+	key     string
+	resp    *IdempotentResponse
+	expires time.Time
+}
+
+// MemoryIdempotencyStore keeps responses in memory for TTL after they are
+// saved, in flight reservations don't expire. When MaxKeys is reached the
+// least recently used key is forgotten, DefaultIdempotencyKeys when 0
+type MemoryIdempotencyStore struct {
+	TTL     time.Duration
+	MaxKeys int
+
+	mu        sync.Mutex
+	entries   map[string]*list.Element
+	lru       *list.List
+	lastSweep time.Time
+}
+
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{TTL: ttl}
+}
+
+func (s *MemoryIdempotencyStore) Begin(ctx context.Context, key string) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.entries == nil {
+		s.entries = make(map[string]*list.Element)
+		s.lru = list.New()
+	}
+	if now.Sub(s.lastSweep) > s.TTL/idempotencySweeps {
+		for elem := s.lru.Front(); elem != nil; {
+			next := elem.Next()
+			if entry := elem.Value.(*idempotencyEntry); entry.resp != nil && now.After(entry.expires) {
+				s.remove(elem)
+			}
+			elem = next
+		}
+		s.lastSweep = now
+	}
+
+	elem, ok := s.entries[key]
+	if !ok {
+		s.store(&idempotencyEntry{key: key})
+		return nil, nil
+	}
+
+	entry := elem.Value.(*idempotencyEntry)
+	s.lru.MoveToFront(elem)
+	switch {
+	case entry.resp != nil && now.After(entry.expires):
+		elem.Value = &idempotencyEntry{key: key}
+		return nil, nil
+	case entry.resp == nil:
+		return nil, ErrIdempotencyInFlight
+	default:
+		return entry.resp, nil
+	}
+}
+
+func (s *MemoryIdempotencyStore) Save(ctx context.Context, key string, resp *IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &idempotencyEntry{key: key, resp: resp, expires: time.Now().Add(s.TTL)}
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.lru.MoveToFront(elem)
+		return nil
+	}
+	if s.entries == nil {
+		s.entries = make(map[string]*list.Element)
+		s.lru = list.New()
+	}
+	s.store(entry)
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+}
+
+// store adds entry as the most recently used, evicting the oldest when full
+func (s *MemoryIdempotencyStore) store(entry *idempotencyEntry) {
+	maxKeys := s.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = DefaultIdempotencyKeys
+	}
+	for s.lru.Len() >= maxKeys {
+		s.remove(s.lru.Back())
+	}
+	s.entries[entry.key] = s.lru.PushFront(entry)
+}
+
+func (s *MemoryIdempotencyStore) remove(elem *list.Element) {
+	s.lru.Remove(elem)
+	delete(s.entries, elem.Value.(*idempotencyEntry).key)
+}
+
+// apiRecordingWriter copies the response for IdempotencyStore.Save
+type apiRecordingWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (w *apiRecordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *apiRecordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body = append(w.body, b...)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *apiRecordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func apiReleaseNothing() {}
+
+// idempotentFingerprint hashes the parsed params, Encode sorts them by name
+func idempotentFingerprint(r *http.Request) string {
+	sum := sha256.Sum256([]byte(r.Form.Encode()))
+	return hex.EncodeToString(sum[:])
+}
+
+// idempotent replays the saved response of the Idempotency-Key, a key reused
+// with other params is answered with 422. Otherwise it records the response,
+// the returned func saves it and must be deferred, server errors and panics
+// release the key so the client may retry
+func (cfg *ApiConfig) idempotent(ctx context.Context, w http.ResponseWriter, r *http.Request, endpoint *ApiEndpoint) (http.ResponseWriter, func(), bool) {
+	header := r.Header.Get("Idempotency-Key")
+	if cfg.Idempotency == nil || header == "" {
+		return w, apiReleaseNothing, true
+	}
+	if len(header) > 255 {
+		writeError(w, r, http.StatusBadRequest, "idempotency key too long")
+		return w, apiReleaseNothing, false
+	}
+
+	principal, _ := PrincipalFromContext(ctx)
+	key := endpoint.Receiver + "." + endpoint.Method + "\x00" + principal.ID + "\x00" + header
+
+	fingerprint := idempotentFingerprint(r)
+	saved, err := cfg.Idempotency.Begin(ctx, key)
+	switch {
+	case errors.Is(err, ErrIdempotencyInFlight):
+		writeError(w, r, http.StatusConflict, "request with the idempotency key is in progress")
+		return w, apiReleaseNothing, false
+	case err != nil:
+		status, message := cfg.errorResponse(r, err)
+		writeError(w, r, status, message)
+		return w, apiReleaseNothing, false
+	case saved != nil && saved.Fingerprint != fingerprint:
+		writeError(w, r, http.StatusUnprocessableEntity, "idempotency key is used with other params")
+		return w, apiReleaseNothing, false
+	case saved != nil:
+		for name, values := range saved.Header {
+			if name != "X-Request-Id" {
+				w.Header()[name] = values
+			}
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(saved.Status)
+		w.Write(saved.Body)
+		return w, apiReleaseNothing, false
+	}
+
+	rw := &apiRecordingWriter{ResponseWriter: w}
+	return rw, func() {
+		ctx := context.WithoutCancel(ctx)
+		if rw.status == 0 || rw.status >= http.StatusInternalServerError {
+			cfg.Idempotency.Release(ctx, key)
+			return
+		}
+
+		err := cfg.Idempotency.Save(ctx, key, &IdempotentResponse{
+			Status:      rw.status,
+			Header:      rw.header,
+			Body:        rw.body,
+			Fingerprint: fingerprint,
+		})
+		if err != nil && cfg.ErrorLogger != nil {
+			cfg.ErrorLogger(r, err)
+		}
+	}, true
+}
+
+type apiPathParam struct {
+	Name  string
+	Value string
+}
+
+type apiRadixRoute[T any] struct {
+	Pattern string
+	Handler func(*T, http.ResponseWriter, *http.Request, *ApiConfig)
+}
+
+// apiRadixNode is a node of the route tree. Static nodes match their prefix,
+// param nodes match one path segment. Routes may name the same param node
+// differently, so names are kept with the handler
+type apiRadixNode[T any] struct {
+	prefix   string
+	param    bool
+	handler  func(*T, http.ResponseWriter, *http.Request, *ApiConfig)
+	names    []string
+	indices  string
+	children []*apiRadixNode[T]
+	wildcard *apiRadixNode[T]
+}
+
+func newApiRadixTree[T any](routes ...apiRadixRoute[T]) *apiRadixNode[T] {
+	root := &apiRadixNode[T]{}
+	for _, route := range routes {
+		root.add(route.Pattern, route.Handler)
+	}
+
+	return root
 }
 
-func checkToken(token string) bool {
-	return token == "100500"
+func (n *apiRadixNode[T]) add(path string, handler func(*T, http.ResponseWriter, *http.Request, *ApiConfig)) {
+	var names []string
+	for path != "" {
+		if path[0] == '{' {
+			end := strings.IndexByte(path, '}')
+			if n.wildcard == nil {
+				n.wildcard = &apiRadixNode[T]{param: true}
+			}
+			names = append(names, path[1:end])
+			n, path = n.wildcard, path[end+1:]
+			continue
+		}
+
+		static := path
+		if end := strings.IndexByte(path, '{'); end >= 0 {
+			static = path[:end]
+		}
+
+		idx := strings.IndexByte(n.indices, static[0])
+		if idx < 0 {
+			child := &apiRadixNode[T]{prefix: static}
+			n.indices += static[:1]
+			n.children = append(n.children, child)
+			n, path = child, path[len(static):]
+			continue
+		}
+
+		child := n.children[idx]
+		common := 0
+		for common < len(child.prefix) && common < len(static) && child.prefix[common] == static[common] {
+			common++
+		}
+
+		if common < len(child.prefix) {
+			tail := *child
+			tail.prefix = child.prefix[common:]
+			*child = apiRadixNode[T]{
+				prefix:   child.prefix[:common],
+				indices:  tail.prefix[:1],
+				children: []*apiRadixNode[T]{&tail},
+			}
+		}
+
+		n, path = child, path[common:]
+	}
+
+	n.handler, n.names = handler, names
 }
 
-func getErrorResponse(err string) []byte {
-	data, _ := json.Marshal(response{
-		Error: err,
-	})
+// lookup prefers static children and falls back to the param child
+func (n *apiRadixNode[T]) lookup(path string, params []apiPathParam) (func(*T, http.ResponseWriter, *http.Request, *ApiConfig), []apiPathParam) {
+	if n.param {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end == 0 {
+			return nil, params
+		}
+
+		params = append(params, apiPathParam{Value: path[:end]})
+		path = path[end:]
+	} else {
+		if !strings.HasPrefix(path, n.prefix) {
+			return nil, params
+		}
+		path = path[len(n.prefix):]
+	}
+
+	if path == "" {
+		if n.handler != nil {
+			named := params[len(params)-len(n.names):]
+			for i := range named {
+				named[i].Name = n.names[i]
+			}
+		}
+		return n.handler, params
+	}
+
+	if idx := strings.IndexByte(n.indices, path[0]); idx >= 0 {
+		if handler, found := n.children[idx].lookup(path, params); handler != nil {
+			return handler, found
+		}
+	}
+
+	if n.wildcard != nil {
+		return n.wildcard.lookup(path, params)
+	}
+
+	return nil, params
+}
+
+// writeError answers with {"error": message}, invalidParams name the
+// request params which failed validation
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string, invalidParams ...string) {
+	writeResponse(w, r, status, response{Error: message})
+}
+
+// registerErrorMappings adds mappings declared with apigen:error
+func registerErrorMappings(cfg *ApiConfig) {
+}
+
+// registerMiddlewares adds functions declared with apigen:middleware
+func registerMiddlewares(cfg *ApiConfig) {
+}
+
+// LogValue hides sensitive ProfileParams fields in access logs
+func (p ProfileParams) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("login", p.Login),
+	)
+}
+
+// LogValue hides sensitive CreateParams fields in access logs
+func (p CreateParams) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("login", p.Login),
+		slog.String("full_name", p.Name),
+		slog.String("status", p.Status),
+		slog.Int("age", p.Age),
+	)
+}
+
+// LogValue hides sensitive OtherCreateParams fields in access logs
+func (p OtherCreateParams) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("username", p.Username),
+		slog.String("account_name", p.Name),
+		slog.String("class", p.Class),
+		slog.Int("level", p.Level),
+	)
+}
 
-	return data
+// AppendJSON appends User encoded like encoding/json does without reflection
+func (v User) AppendJSON(dst []byte) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, ",\"id\":"...)
+	dst = strconv.AppendUint(dst, uint64(v.ID), 10)
+	dst = append(dst, ",\"login\":"...)
+	dst = appendJSONString(dst, v.Login)
+	dst = append(dst, ",\"full_name\":"...)
+	dst = appendJSONString(dst, v.FullName)
+	dst = append(dst, ",\"status\":"...)
+	dst = strconv.AppendInt(dst, int64(v.Status), 10)
+	if len(dst) == start {
+		dst = append(dst, '{')
+	} else {
+		dst[start] = '{'
+	}
+	return append(dst, '}'), nil
+}
+
+// AppendJSON appends NewUser encoded like encoding/json does without reflection
+func (v NewUser) AppendJSON(dst []byte) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, ",\"id\":"...)
+	dst = strconv.AppendUint(dst, uint64(v.ID), 10)
+	if len(dst) == start {
+		dst = append(dst, '{')
+	} else {
+		dst[start] = '{'
+	}
+	return append(dst, '}'), nil
 }
 
-	// MyApi
+// AppendJSON appends OtherUser encoded like encoding/json does without reflection
+func (v OtherUser) AppendJSON(dst []byte) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, ",\"id\":"...)
+	dst = strconv.AppendUint(dst, uint64(v.ID), 10)
+	dst = append(dst, ",\"login\":"...)
+	dst = appendJSONString(dst, v.Login)
+	dst = append(dst, ",\"full_name\":"...)
+	dst = appendJSONString(dst, v.FullName)
+	dst = append(dst, ",\"level\":"...)
+	dst = strconv.AppendInt(dst, int64(v.Level), 10)
+	if len(dst) == start {
+		dst = append(dst, '{')
+	} else {
+		dst[start] = '{'
+	}
+	return append(dst, '}'), nil
+}
+
+var radixMyApi = newApiRadixTree(
+	apiRadixRoute[MyApi]{"/user/profile", (*MyApi).handlerProfile},
+	apiRadixRoute[MyApi]{"/user/create", (*MyApi).handlerCreate},
+	apiRadixRoute[MyApi]{"/user/signup", (*MyApi).handlerSignup},
+	apiRadixRoute[MyApi]{"/user/feed", (*MyApi).handlerFeed},
+)
+
+// ServeHTTP serves MyApi with DefaultApiConfig
 func (h *MyApi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	switch r.URL.Path {
-		
-		case "/user/profile":
-			h.handlerProfile(w, r)
-		
-		case "/user/create":
-			h.handlerCreate(w, r)
-		
-		default:
-			w.WriteHeader(http.StatusNotFound)
+	h.serveApi(w, r, DefaultApiConfig)
+}
+
+func (h *MyApi) serveApi(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+	var buf [4]apiPathParam
+
+	handler, params := radixMyApi.lookup(r.URL.Path, buf[:0])
+	if handler == nil {
+		writeError(w, r, http.StatusNotFound, "unknown method")
+		return
+	}
+
+	for _, p := range params {
+		r.SetPathValue(p.Name, p.Value)
 	}
+
+	handler(h, w, r, cfg)
+}
+
+
+// MyApiHandler serves MyApi with Config, DefaultApiConfig when nil
+type MyApiHandler struct {
+	Api    *MyApi
+	Config *ApiConfig
+}
+
+func NewMyApiHandler(api *MyApi, cfg *ApiConfig) *MyApiHandler {
+	return &MyApiHandler{Api: api, Config: cfg}
+}
+
+func (h *MyApiHandler) apiConfig() *ApiConfig {
+	if h.Config == nil {
+		return DefaultApiConfig
+	}
+
+	return h.Config
+}
+
+func (h *MyApiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Api.serveApi(w, r, h.apiConfig())
 }
 
-func (h *MyApi) handlerProfile(w http.ResponseWriter, r *http.Request) {
+var apiEndpointMyApiProfile = &ApiEndpoint{
+	Receiver:   "MyApi",
+	Method:     "Profile",
+	HTTPMethod: "",
+	Route:      "/user/profile",
+	Auth:       false,
+}
+
+func (h *MyApi) handlerProfile(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+	w, r, done := cfg.observe(w, r, apiEndpointMyApiProfile)
+	defer done()
+	defer cfg.recoverPanic(w, r)
+
+	acceptable := negotiate(w, r)
+	ctx := r.Context()
+
+	if !acceptable {
+		writeError(w, r, http.StatusNotAcceptable, "not acceptable")
+		return
+	}
 
+	_, validatePhase := cfg.startPhase(ctx, "validate")
+	defer validatePhase.end(ErrRequestRejected)
 	params := ProfileParams{}
 
 	login := r.FormValue("login")
 
 	// required
 	if login == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(getErrorResponse("login must me not empty"))
+		writeError(w, r, http.StatusBadRequest, "login must me not empty", "login")
 		return
 	}
 	
 	params.Login = login
 
-	ctx := context.Background()
+	if info := requestInfo(r); info != nil {
+		info.params = params
+	}
+	validatePhase.end(nil)
+
+	callCtx, callPhase := cfg.startPhase(ctx, "call")
+	resp, err := h.Profile(callCtx, params)
+	callPhase.end(err)
+	if err != nil {
+		status, message := cfg.errorResponse(r, err)
+		writeError(w, r, status, message)
+		return
+	}
+
+	_, encodePhase := cfg.startPhase(ctx, "encode")
+	writeResult(w, r, http.StatusOK, resp, response{Response: resp})
+	encodePhase.end(nil)
+	
+}
+
+var apiEndpointMyApiCreate = &ApiEndpoint{
+	Receiver:   "MyApi",
+	Method:     "Create",
+	HTTPMethod: "POST",
+	Route:      "/user/create",
+	Auth:       true,
+}
 
-	result := response{}
+func (h *MyApi) handlerCreate(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+	w, r, done := cfg.observe(w, r, apiEndpointMyApiCreate)
+	defer done()
+	defer cfg.recoverPanic(w, r)
+
+	acceptable := negotiate(w, r)
+	ctx := r.Context()
+
+	// check http method
+	method := "POST"
+	if r.Method != method {
+		writeError(w, r, http.StatusNotAcceptable, "bad method")
+		return
+	}
+	
+	_, authPhase := cfg.startPhase(ctx, "auth")
+	defer authPhase.end(ErrRequestRejected)
 
-	resp, err := h.Profile(ctx, params)
+	// check auth
+	ctx, err := cfg.authenticate(r)
 	if err != nil {
-		apiError := err.(ApiError)
+		writeError(w, r, http.StatusForbidden, "unauthorized")
+		return
+	}
+	if info := requestInfo(r); info != nil {
+		principal, _ := PrincipalFromContext(ctx)
+		info.principal = principal.ID
+	}
+	authPhase.end(nil)
 
-		w.WriteHeader(apiError.HTTPStatus)
-		result.Error = apiError.Error()
+	if !acceptable {
+		writeError(w, r, http.StatusNotAcceptable, "not acceptable")
+		return
+	}
+
+	_, validatePhase := cfg.startPhase(ctx, "validate")
+	defer validatePhase.end(ErrRequestRejected)
+	params := CreateParams{}
 
-		data, _ := json.Marshal(result)
-		w.Write(data)
+	login := r.FormValue("login")
+
+	// required
+	if login == "" {
+		writeError(w, r, http.StatusBadRequest, "login must me not empty", "login")
 		return
 	}
+	
+	// min
+	if len(login) < 10 {
+    	writeError(w, r, http.StatusBadRequest, "login len must be >= 10", "login")
+    	return
+	}
+	
+	params.Login = login
+
+	name := r.FormValue("full_name")
+
+	params.Name = name
+
+	status := r.FormValue("status")
+
+	// default
+	if status == "" {
+		status = "user"
+	}
+	
+	// enum
+	if !(status == "user" || status == "moderator" || status == "admin") {
+    	writeError(w, r, http.StatusBadRequest, "status must be one of [user, moderator, admin]", "status")
+    	return
+	}
+	
+	params.Status = status
 
-	data, _ := json.Marshal(resp)
-	result.Response = data
+	age := r.FormValue("age")
 
-	data, _ = json.Marshal(result)
+	// cast to int
+	ageInt, err := strconv.Atoi(age)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "age must be int", "age")
+		return
+	}
+	
+	// min
+	if ageInt < 0 {
+    	writeError(w, r, http.StatusBadRequest, "age must be >= 0", "age")
+    	return
+	}
+	
+	// max
+	if ageInt > 128 {
+    	writeError(w, r, http.StatusBadRequest, "age must be <= 128", "age")
+    	return
+	}
 	
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	params.Age = ageInt
+
+	if info := requestInfo(r); info != nil {
+		info.params = params
+	}
+	validatePhase.end(nil)
+
+	callCtx, callPhase := cfg.startPhase(ctx, "call")
+	resp, err := h.Create(callCtx, params)
+	callPhase.end(err)
+	if err != nil {
+		status, message := cfg.errorResponse(r, err)
+		writeError(w, r, status, message)
+		return
+	}
+
+	_, encodePhase := cfg.startPhase(ctx, "encode")
+	writeResult(w, r, http.StatusOK, resp, response{Response: resp})
+	encodePhase.end(nil)
 	
 }
 
-func (h *MyApi) handlerCreate(w http.ResponseWriter, r *http.Request) {
+var apiEndpointMyApiSignup = &ApiEndpoint{
+	Receiver:   "MyApi",
+	Method:     "Signup",
+	HTTPMethod: "POST",
+	Route:      "/user/signup",
+	Auth:       true,
+	RateLimit:  &ApiRateLimit{Rate: 10, Burst: 20, Key: "ip"},
+}
+
+func (h *MyApi) handlerSignup(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+	w, r, done := cfg.observe(w, r, apiEndpointMyApiSignup)
+	defer done()
+	defer cfg.recoverPanic(w, r)
+
+	acceptable := negotiate(w, r)
+	ctx := r.Context()
 
 	// check http method
 	method := "POST"
 	if r.Method != method {
-		w.WriteHeader(http.StatusNotAcceptable)
-		w.Write(getErrorResponse("bad method"))
+		writeError(w, r, http.StatusNotAcceptable, "bad method")
 		return
 	}
 	
+	_, authPhase := cfg.startPhase(ctx, "auth")
+	defer authPhase.end(ErrRequestRejected)
+
 	// check auth
-	token := r.Header.Get("X-Auth")
-	if !checkToken(token) {
-		w.WriteHeader(http.StatusForbidden)
-		w.Write(getErrorResponse("unathorized"))
+	ctx, err := cfg.authenticate(r)
+	if err != nil {
+		writeError(w, r, http.StatusForbidden, "unauthorized")
 		return
 	}
-	
+	if info := requestInfo(r); info != nil {
+		principal, _ := PrincipalFromContext(ctx)
+		info.principal = principal.ID
+	}
+	authPhase.end(nil)
+
+	if !acceptable {
+		writeError(w, r, http.StatusNotAcceptable, "not acceptable")
+		return
+	}
+
+	// check rate limit
+	if !cfg.allowRequest(ctx, w, r, apiEndpointMyApiSignup) {
+		return
+	}
+
+	// check body
+	if !checkBody(w, r, 1 << 20, "application/x-www-form-urlencoded") {
+		return
+	}
+
+	_, validatePhase := cfg.startPhase(ctx, "validate")
+	defer validatePhase.end(ErrRequestRejected)
 	params := CreateParams{}
 
 	login := r.FormValue("login")
 
 	// required
 	if login == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(getErrorResponse("login must me not empty"))
+		writeError(w, r, http.StatusBadRequest, "login must me not empty", "login")
 		return
 	}
 	
 	// min
-	if len(login) < 0 {
-    	w.WriteHeader(http.StatusBadRequest)
-    	w.Write(getErrorResponse("login len must be >= 0"))
+	if len(login) < 10 {
+    	writeError(w, r, http.StatusBadRequest, "login len must be >= 10", "login")
     	return
 	}
 	
@@ -131,8 +2460,7 @@ func (h *MyApi) handlerCreate(w http.ResponseWriter, r *http.Request) {
 	
 	// enum
 	if !(status == "user" || status == "moderator" || status == "admin") {
-    	w.WriteHeader(http.StatusBadRequest)
-    	w.Write(getErrorResponse("status must be one of [user, moderator, admin]"))
+    	writeError(w, r, http.StatusBadRequest, "status must be one of [user, moderator, admin]", "status")
     	return
 	}
 	
@@ -143,98 +2471,228 @@ func (h *MyApi) handlerCreate(w http.ResponseWriter, r *http.Request) {
 	// cast to int
 	ageInt, err := strconv.Atoi(age)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(getErrorResponse("age must be int"))
+		writeError(w, r, http.StatusBadRequest, "age must be int", "age")
+		return
 	}
 	
 	// min
-	if ageInt < 128 {
-    	w.WriteHeader(http.StatusBadRequest)
-    	w.Write(getErrorResponse("age must be >= 128"))
+	if ageInt < 0 {
+    	writeError(w, r, http.StatusBadRequest, "age must be >= 0", "age")
     	return
 	}
 	
 	// max
 	if ageInt > 128 {
-    	w.WriteHeader(http.StatusBadRequest)
-    	w.Write(getErrorResponse("age must be <= 128"))
+    	writeError(w, r, http.StatusBadRequest, "age must be <= 128", "age")
     	return
 	}
 	
 	params.Age = ageInt
 
-	ctx := context.Background()
+	if info := requestInfo(r); info != nil {
+		info.params = params
+	}
+	validatePhase.end(nil)
 
-	result := response{}
+	// check idempotency key
+	w, release, ok := cfg.idempotent(ctx, w, r, apiEndpointMyApiSignup)
+	if !ok {
+		return
+	}
+	defer release()
 
-	resp, err := h.Create(ctx, params)
+	callCtx, callPhase := cfg.startPhase(ctx, "call")
+	resp, err := h.Signup(callCtx, params)
+	callPhase.end(err)
 	if err != nil {
-		apiError := err.(ApiError)
+		status, message := cfg.errorResponse(r, err)
+		writeError(w, r, status, message)
+		return
+	}
+
+	_, encodePhase := cfg.startPhase(ctx, "encode")
+	writeResult(w, r, http.StatusOK, resp, response{Response: resp})
+	encodePhase.end(nil)
+	
+}
+
+var apiEndpointMyApiFeed = &ApiEndpoint{
+	Receiver:   "MyApi",
+	Method:     "Feed",
+	HTTPMethod: "",
+	Route:      "/user/feed",
+	Auth:       true,
+	Stream:     "ndjson",
+}
+
+func (h *MyApi) handlerFeed(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+	w, r, done := cfg.observe(w, r, apiEndpointMyApiFeed)
+	defer done()
+	defer cfg.recoverPanic(w, r)
 
-		w.WriteHeader(apiError.HTTPStatus)
-		result.Error = apiError.Error()
+	ctx := r.Context()
 
-		data, _ := json.Marshal(result)
-		w.Write(data)
+	_, authPhase := cfg.startPhase(ctx, "auth")
+	defer authPhase.end(ErrRequestRejected)
+
+	// check auth
+	ctx, err := cfg.authenticate(r, "jwt", "basic", "apikey", "cookie", "token")
+	if err != nil {
+		writeError(w, r, http.StatusForbidden, "unauthorized")
 		return
 	}
+	if info := requestInfo(r); info != nil {
+		principal, _ := PrincipalFromContext(ctx)
+		info.principal = principal.ID
+	}
+	authPhase.end(nil)
 
-	data, _ := json.Marshal(resp)
-	result.Response = data
+	_, validatePhase := cfg.startPhase(ctx, "validate")
+	defer validatePhase.end(ErrRequestRejected)
+	params := ProfileParams{}
 
-	data, _ = json.Marshal(result)
-	
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	login := r.FormValue("login")
+
+	// required
+	if login == "" {
+		writeError(w, r, http.StatusBadRequest, "login must me not empty", "login")
+		return
+	}
 	
+	params.Login = login
+
+	if info := requestInfo(r); info != nil {
+		info.params = params
+	}
+	validatePhase.end(nil)
+
+	stream := newApiStream(w, false)
+	callCtx, callPhase := cfg.startPhase(ctx, "call")
+	if err := h.Feed(callCtx, params, func(item *User) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return stream.send(item)
+	}); err != nil {
+		callPhase.end(err)
+		if r.Context().Err() == nil {
+			status, message := cfg.errorResponse(r, err)
+			stream.fail(r, status, message)
+		}
+		return
+	}
+	callPhase.end(nil)
+	stream.close()
+
 }
 
 
-	// OtherApi
+var radixOtherApi = newApiRadixTree(
+	apiRadixRoute[OtherApi]{"/user/create", (*OtherApi).handlerCreate},
+)
+
+// ServeHTTP serves OtherApi with DefaultApiConfig
 func (h *OtherApi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	switch r.URL.Path {
-		
-		case "/user/create":
-			h.handlerCreate(w, r)
-		
-		default:
-			w.WriteHeader(http.StatusNotFound)
+	h.serveApi(w, r, DefaultApiConfig)
+}
+
+func (h *OtherApi) serveApi(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+	var buf [4]apiPathParam
+
+	handler, params := radixOtherApi.lookup(r.URL.Path, buf[:0])
+	if handler == nil {
+		writeError(w, r, http.StatusNotFound, "unknown method")
+		return
+	}
+
+	for _, p := range params {
+		r.SetPathValue(p.Name, p.Value)
+	}
+
+	handler(h, w, r, cfg)
+}
+
+
+// OtherApiHandler serves OtherApi with Config, DefaultApiConfig when nil
+type OtherApiHandler struct {
+	Api    *OtherApi
+	Config *ApiConfig
+}
+
+func NewOtherApiHandler(api *OtherApi, cfg *ApiConfig) *OtherApiHandler {
+	return &OtherApiHandler{Api: api, Config: cfg}
+}
+
+func (h *OtherApiHandler) apiConfig() *ApiConfig {
+	if h.Config == nil {
+		return DefaultApiConfig
 	}
+
+	return h.Config
+}
+
+func (h *OtherApiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Api.serveApi(w, r, h.apiConfig())
 }
 
-func (h *OtherApi) handlerCreate(w http.ResponseWriter, r *http.Request) {
+var apiEndpointOtherApiCreate = &ApiEndpoint{
+	Receiver:   "OtherApi",
+	Method:     "Create",
+	HTTPMethod: "POST",
+	Route:      "/user/create",
+	Auth:       true,
+}
+
+func (h *OtherApi) handlerCreate(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+	w, r, done := cfg.observe(w, r, apiEndpointOtherApiCreate)
+	defer done()
+	defer cfg.recoverPanic(w, r)
+
+	acceptable := negotiate(w, r)
+	ctx := r.Context()
 
 	// check http method
 	method := "POST"
 	if r.Method != method {
-		w.WriteHeader(http.StatusNotAcceptable)
-		w.Write(getErrorResponse("bad method"))
+		writeError(w, r, http.StatusNotAcceptable, "bad method")
 		return
 	}
 	
+	_, authPhase := cfg.startPhase(ctx, "auth")
+	defer authPhase.end(ErrRequestRejected)
+
 	// check auth
-	token := r.Header.Get("X-Auth")
-	if !checkToken(token) {
-		w.WriteHeader(http.StatusForbidden)
-		w.Write(getErrorResponse("unathorized"))
+	ctx, err := cfg.authenticate(r)
+	if err != nil {
+		writeError(w, r, http.StatusForbidden, "unauthorized")
 		return
 	}
-	
+	if info := requestInfo(r); info != nil {
+		principal, _ := PrincipalFromContext(ctx)
+		info.principal = principal.ID
+	}
+	authPhase.end(nil)
+
+	if !acceptable {
+		writeError(w, r, http.StatusNotAcceptable, "not acceptable")
+		return
+	}
+
+	_, validatePhase := cfg.startPhase(ctx, "validate")
+	defer validatePhase.end(ErrRequestRejected)
 	params := OtherCreateParams{}
 
 	username := r.FormValue("username")
 
 	// required
 	if username == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(getErrorResponse("username must me not empty"))
+		writeError(w, r, http.StatusBadRequest, "username must me not empty", "username")
 		return
 	}
 	
 	// min
-	if len(username) < 0 {
-    	w.WriteHeader(http.StatusBadRequest)
-    	w.Write(getErrorResponse("username len must be >= 0"))
+	if len(username) < 3 {
+    	writeError(w, r, http.StatusBadRequest, "username len must be >= 3", "username")
     	return
 	}
 	
@@ -253,8 +2711,7 @@ func (h *OtherApi) handlerCreate(w http.ResponseWriter, r *http.Request) {
 	
 	// enum
 	if !(class == "warrior" || class == "sorcerer" || class == "rouge") {
-    	w.WriteHeader(http.StatusBadRequest)
-    	w.Write(getErrorResponse("class must be one of [warrior, sorcerer, rouge]"))
+    	writeError(w, r, http.StatusBadRequest, "class must be one of [warrior, sorcerer, rouge]", "class")
     	return
 	}
 	
@@ -265,49 +2722,129 @@ func (h *OtherApi) handlerCreate(w http.ResponseWriter, r *http.Request) {
 	// cast to int
 	levelInt, err := strconv.Atoi(level)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(getErrorResponse("level must be int"))
+		writeError(w, r, http.StatusBadRequest, "level must be int", "level")
+		return
 	}
 	
 	// min
-	if levelInt < 50 {
-    	w.WriteHeader(http.StatusBadRequest)
-    	w.Write(getErrorResponse("level must be >= 50"))
+	if levelInt < 1 {
+    	writeError(w, r, http.StatusBadRequest, "level must be >= 1", "level")
     	return
 	}
 	
 	// max
 	if levelInt > 50 {
-    	w.WriteHeader(http.StatusBadRequest)
-    	w.Write(getErrorResponse("level must be <= 50"))
+    	writeError(w, r, http.StatusBadRequest, "level must be <= 50", "level")
     	return
 	}
 	
 	params.Level = levelInt
 
-	ctx := context.Background()
-
-	result := response{}
+	if info := requestInfo(r); info != nil {
+		info.params = params
+	}
+	validatePhase.end(nil)
 
-	resp, err := h.Create(ctx, params)
+	callCtx, callPhase := cfg.startPhase(ctx, "call")
+	resp, err := h.Create(callCtx, params)
+	callPhase.end(err)
 	if err != nil {
-		apiError := err.(ApiError)
+		status, message := cfg.errorResponse(r, err)
+		writeError(w, r, status, message)
+		return
+	}
+
+	_, encodePhase := cfg.startPhase(ctx, "encode")
+	writeResult(w, r, http.StatusOK, resp, response{Response: resp})
+	encodePhase.end(nil)
+	
+}
+
+
+// ApiRoute describes a single endpoint served by a generated router
+type ApiRoute struct {
+	Receiver string
+	FuncName string
+	Method   string
+	Path     string
+	Auth     bool
+}
+
+// ApiRouter serves the mounted receivers under their prefixes
+type ApiRouter struct {
+	MyApi *MyApi
+	OtherApi *OtherApi
+
+	// Config is used by all mounted receivers, DefaultApiConfig when nil
+	Config *ApiConfig
+}
+
+func NewApiRouter(myApi *MyApi, otherApi *OtherApi) *ApiRouter {
+	return &ApiRouter{
+		MyApi: myApi,
+		OtherApi: otherApi,
+		Config: DefaultApiConfig,
+	}
+}
+
+func (rt *ApiRouter) apiConfig() *ApiConfig {
+	if rt.Config == nil {
+		return DefaultApiConfig
+	}
+
+	return rt.Config
+}
+
+var routesApiRouter = []ApiRoute{
+	{Receiver: "MyApi", FuncName: "Profile", Method: "", Path: "/my/user/profile", Auth: false},
+	{Receiver: "MyApi", FuncName: "Create", Method: "POST", Path: "/my/user/create", Auth: true},
+	{Receiver: "MyApi", FuncName: "Signup", Method: "POST", Path: "/my/user/signup", Auth: true},
+	{Receiver: "MyApi", FuncName: "Feed", Method: "", Path: "/my/user/feed", Auth: true},
+	{Receiver: "OtherApi", FuncName: "Create", Method: "POST", Path: "/other/user/create", Auth: true},
+}
+
+// Routes returns the combined route table of all mounted receivers
+func (rt *ApiRouter) Routes() []ApiRoute {
+	return append([]ApiRoute(nil), routesApiRouter...)
+}
 
-		w.WriteHeader(apiError.HTTPStatus)
-		result.Error = apiError.Error()
 
-		data, _ := json.Marshal(result)
-		w.Write(data)
+var radixApiRouter = newApiRadixTree(
+	apiRadixRoute[ApiRouter]{"/my/user/profile", func(rt *ApiRouter, w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+		rt.MyApi.handlerProfile(w, r, cfg)
+	}},
+	apiRadixRoute[ApiRouter]{"/my/user/create", func(rt *ApiRouter, w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+		rt.MyApi.handlerCreate(w, r, cfg)
+	}},
+	apiRadixRoute[ApiRouter]{"/my/user/signup", func(rt *ApiRouter, w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+		rt.MyApi.handlerSignup(w, r, cfg)
+	}},
+	apiRadixRoute[ApiRouter]{"/my/user/feed", func(rt *ApiRouter, w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+		rt.MyApi.handlerFeed(w, r, cfg)
+	}},
+	apiRadixRoute[ApiRouter]{"/other/user/create", func(rt *ApiRouter, w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+		rt.OtherApi.handlerCreate(w, r, cfg)
+	}},
+)
+
+// ServeHTTP serves ApiRouter with h.apiConfig()
+func (h *ApiRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.serveApi(w, r, h.apiConfig())
+}
+
+func (h *ApiRouter) serveApi(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+	var buf [4]apiPathParam
+
+	handler, params := radixApiRouter.lookup(r.URL.Path, buf[:0])
+	if handler == nil {
+		writeError(w, r, http.StatusNotFound, "unknown method")
 		return
 	}
 
-	data, _ := json.Marshal(resp)
-	result.Response = data
+	for _, p := range params {
+		r.SetPathValue(p.Name, p.Value)
+	}
 
-	data, _ = json.Marshal(result)
-	
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
-	
+	handler(h, w, r, cfg)
 }
 
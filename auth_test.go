@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signTestJWT(alg string, secret []byte, claims map[string]any) string {
+	newHash := map[string]func() hash.Hash{"HS256": sha256.New, "HS512": sha512.New}[alg]
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	if newHash == nil {
+		return unsigned + "."
+	}
+
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(unsigned))
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1700000000, 0)
+	auth := &JWTAuthenticator{
+		Secret:   secret,
+		Audience: "api",
+		Now:      func() time.Time { return now },
+	}
+
+	valid := map[string]any{"sub": "rvasily", "roles": []string{"admin"}, "aud": "api", "exp": now.Unix() + 60}
+
+	cases := []struct {
+		Name  string
+		Token string
+		Err   error
+	}{
+		{"hs256", signTestJWT("HS256", secret, valid), nil},
+		{"hs512", signTestJWT("HS512", secret, valid), nil},
+		{"audience list", signTestJWT("HS256", secret, map[string]any{"sub": "rvasily", "aud": []string{"web", "api"}}), nil},
+		{"wrong secret", signTestJWT("HS256", []byte("other"), valid), errJWTSignature},
+		{"alg none", signTestJWT("none", secret, valid), errJWTAlgorithm},
+		{"expired", signTestJWT("HS256", secret, map[string]any{"aud": "api", "exp": now.Unix()}), errJWTExpired},
+		{"not before", signTestJWT("HS256", secret, map[string]any{"aud": "api", "nbf": now.Unix() + 10}), errJWTNotBefore},
+		{"audience", signTestJWT("HS256", secret, map[string]any{"aud": "web"}), errJWTAudience},
+		{"malformed", "abc.def", errJWTMalformed},
+		{"string exp", signTestJWT("HS256", secret, map[string]any{"aud": "api", "exp": "1"}), errJWTMalformed},
+		{"null nbf", signTestJWT("HS256", secret, map[string]any{"aud": "api", "nbf": nil}), errJWTMalformed},
+		{"huge exp", signTestJWT("HS256", secret, map[string]any{"sub": "rvasily", "aud": "api", "exp": 1e300}), errJWTMalformed},
+		{"far exp", signTestJWT("HS256", secret, map[string]any{"sub": "rvasily", "aud": "api", "exp": 1e12 + 0.5}), nil},
+	}
+
+	for _, item := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+item.Token)
+
+		ctx, err := auth.Authenticate(r)
+		if err != item.Err {
+			t.Errorf("[%s] expected error %v, got %v", item.Name, item.Err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		principal, _ := PrincipalFromContext(ctx)
+		claims, ok := ClaimsFromContext(ctx)
+		if principal.ID != "rvasily" || !ok || claims["sub"] != "rvasily" {
+			t.Errorf("[%s] unexpected principal %#v and claims %#v", item.Name, principal, claims)
+		}
+	}
+
+	// с пустым секретом подпись HMAC подделывается, такой токен не принимается
+	if _, err := (&JWTAuthenticator{}).Verify(signTestJWT("HS256", nil, valid)); err != errJWTNoSecret {
+		t.Errorf("expected errJWTNoSecret without secret, got %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, err := auth.Authenticate(r); err != errUnauthorized {
+		t.Errorf("expected unauthorized without bearer token, got %v", err)
+	}
+
+	r.Header.Set("Authorization", "Bearer "+signTestJWT("HS256", secret, valid))
+	ctx, _ := auth.Authenticate(r)
+	if !hasAnyRole(ctx, "admin") {
+		t.Error("roles claim is not exposed to role checks")
+	}
+}
+
+func TestAuthenticateSchemes(t *testing.T) {
+	verified := func(id string) Principal { return Principal{ID: id} }
+	cfg := NewApiConfig()
+	cfg.Authenticators["basic"] = BasicAuthenticator{
+		Verify: func(ctx context.Context, user, password string) (Principal, error) {
+			if user != "admin" || password != "qwerty" {
+				return Principal{}, errUnauthorized
+			}
+			return verified("basic:" + user), nil
+		},
+	}
+	cfg.Authenticators["apikey"] = APIKeyAuthenticator{
+		Header: "X-Api-Key",
+		Query:  "api_key",
+		Verify: func(ctx context.Context, key string) (Principal, error) {
+			if key != "partner" {
+				return Principal{}, errUnauthorized
+			}
+			return verified("apikey:" + key), nil
+		},
+	}
+	cfg.Authenticators["cookie"] = CookieAuthenticator{
+		Name: "session",
+		Verify: func(ctx context.Context, session string) (Principal, error) {
+			return verified("cookie:" + session), nil
+		},
+	}
+
+	cases := []struct {
+		Name      string
+		Prepare   func(r *http.Request)
+		Principal string
+	}{
+		{"basic", func(r *http.Request) { r.SetBasicAuth("admin", "qwerty") }, "basic:admin"},
+		{"basic wrong password", func(r *http.Request) { r.SetBasicAuth("admin", "123") }, ""},
+		{"apikey header", func(r *http.Request) { r.Header.Set("X-Api-Key", "partner") }, "apikey:partner"},
+		{"apikey query", func(r *http.Request) { r.URL.RawQuery = "api_key=partner" }, "apikey:partner"},
+		{"cookie", func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "session", Value: "s1"}) }, "cookie:s1"},
+		{"token", func(r *http.Request) { r.Header.Set("X-Auth", "100500") }, "100500"},
+		{"first scheme wins", func(r *http.Request) {
+			r.SetBasicAuth("admin", "qwerty")
+			r.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+		}, "basic:admin"},
+		{"nothing", func(r *http.Request) {}, ""},
+	}
+
+	for _, item := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		item.Prepare(r)
+
+		ctx, err := cfg.authenticate(r, "basic", "apikey", "cookie", "token")
+		if item.Principal == "" {
+			if err == nil {
+				t.Errorf("[%s] expected error", item.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%s] unexpected error %v", item.Name, err)
+			continue
+		}
+		if principal, _ := PrincipalFromContext(ctx); principal.ID != item.Principal {
+			t.Errorf("[%s] expected principal %s, got %s", item.Name, item.Principal, principal.ID)
+		}
+	}
+}
+
+func TestAuthenticateWithoutAuthenticator(t *testing.T) {
+	cfg := NewApiConfig()
+	cfg.Authenticator = nil
+	cfg.Authenticators["jwt"] = nil
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Auth", "100500")
+	for _, schemes := range [][]string{nil, {"token"}, {"jwt", "token"}} {
+		if _, err := cfg.authenticate(r, schemes...); err != errUnauthorized {
+			t.Errorf("%v: expected errUnauthorized, got %v", schemes, err)
+		}
+	}
+}
+
+func TestReceiverHandler(t *testing.T) {
+	// у MyApiHandler свой набор токенов, сам MyApi остается на DefaultApiConfig
+	cfg := NewApiConfig()
+	cfg.Authenticator = TokenAuthenticator{
+		Header: "X-Auth",
+		Tokens: map[string]Principal{"partner": {ID: "partner"}},
+	}
+	partner := NewMyApiHandler(NewMyApi(), cfg)
+
+	for _, item := range []struct {
+		Handler http.Handler
+		Token   string
+		Status  int
+	}{
+		{NewMyApi(), "100500", http.StatusOK},
+		{NewMyApi(), "partner", http.StatusForbidden},
+		{partner, "100500", http.StatusForbidden},
+		{partner, "partner", http.StatusOK},
+		{NewMyApiHandler(NewMyApi(), nil), "100500", http.StatusOK},
+	} {
+		r := httptest.NewRequest(http.MethodPost, ApiUserCreate, strings.NewReader("login=mr.moderator&age=32"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("X-Auth", item.Token)
+		w := httptest.NewRecorder()
+		item.Handler.ServeHTTP(w, r)
+
+		if w.Code != item.Status {
+			t.Errorf("[%T %s] expected %d, got %d", item.Handler, item.Token, item.Status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimits(t *testing.T) {
+	router := NewApiRouter(NewMyApi(), NewOtherApi())
+	router.Config = NewApiConfig()
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	for _, c := range []struct {
+		Name        string
+		ContentType string
+		Body        string
+		Status      int
+	}{
+		{"form", "application/x-www-form-urlencoded; charset=utf-8", "login=mr.moderator&age=32", http.StatusOK},
+		{"json", "application/json", `{"login": "mr.moderator"}`, http.StatusUnsupportedMediaType},
+		{"no content type", "", "login=mr.moderator", http.StatusUnsupportedMediaType},
+		// тело больше 1MB не дочитывается до конца
+		{"too large", "application/x-www-form-urlencoded", "login=mr.moderator&padding=" + strings.Repeat("a", 1<<20), http.StatusRequestEntityTooLarge},
+		{"bad form", "application/x-www-form-urlencoded", "login=%zz", http.StatusBadRequest},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/my"+ApiUserSignup, strings.NewReader(c.Body))
+		if c.ContentType != "" {
+			req.Header.Set("Content-Type", c.ContentType)
+		}
+		req.Header.Set("X-Auth", "100500")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != c.Status {
+			t.Errorf("%s: expected %d, got %d", c.Name, c.Status, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorResponse(t *testing.T) {
+	var logged []error
+	cfg := NewApiConfig()
+	cfg.ErrorLogger = func(r *http.Request, err error) {
+		logged = append(logged, err)
+	}
+
+	notFound := ApiError{http.StatusNotFound, fmt.Errorf("user not exist")}
+	cases := []struct {
+		Err     error
+		Status  int
+		Message string
+	}{
+		{notFound, http.StatusNotFound, "user not exist"},
+		{&notFound, http.StatusNotFound, "user not exist"},
+		{fmt.Errorf("profile: %w", notFound), http.StatusNotFound, "user not exist"},
+		{fmt.Errorf("profile: %w", &notFound), http.StatusNotFound, "user not exist"},
+		{errors.New("bad user"), http.StatusInternalServerError, "internal error"},
+		{fmt.Errorf("db: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "timeout"},
+		{context.Canceled, http.StatusServiceUnavailable, "request canceled"},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, ApiUserProfile, nil)
+	for _, item := range cases {
+		status, message := cfg.errorResponse(r, item.Err)
+		if status != item.Status || message != item.Message {
+			t.Errorf("[%v] expected %d %q, got %d %q", item.Err, item.Status, item.Message, status, message)
+		}
+	}
+
+	if len(logged) != 1 || logged[0].Error() != "bad user" {
+		t.Errorf("unexpected logged errors %v", logged)
+	}
+}
+
+type testConflictError struct {
+	Login string
+}
+
+func (e *testConflictError) Error() string {
+	return "user " + e.Login + " exist"
+}
+
+func TestErrorMappings(t *testing.T) {
+	errTestNotFound := errors.New("not found in storage")
+
+	cfg := NewApiConfig()
+	cfg.ErrorLogger = nil
+	cfg.RegisterErrorMapping(errTestNotFound, http.StatusNotFound, "user not exist")
+	RegisterErrorTypeMapping[*testConflictError](cfg, http.StatusConflict, "")
+
+	cases := []struct {
+		Err     error
+		Status  int
+		Message string
+	}{
+		{errTestNotFound, http.StatusNotFound, "user not exist"},
+		{fmt.Errorf("profile: %w", errTestNotFound), http.StatusNotFound, "user not exist"},
+		{fmt.Errorf("create: %w", &testConflictError{"rvasily"}), http.StatusConflict, "user rvasily exist"},
+		{ApiError{http.StatusBadRequest, errTestNotFound}, http.StatusBadRequest, "not found in storage"},
+		{errors.New("not found in storage"), http.StatusInternalServerError, "internal error"},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, ApiUserProfile, nil)
+	for _, item := range cases {
+		status, message := cfg.errorResponse(r, item.Err)
+		if status != item.Status || message != item.Message {
+			t.Errorf("[%v] expected %d %q, got %d %q", item.Err, item.Status, item.Message, status, message)
+		}
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	var recovered []any
+	router := NewApiRouter(&MyApi{}, nil)
+	router.Config = NewApiConfig()
+	router.Config.PanicHook = func(r *http.Request, value any, stack []byte) {
+		recovered = append(recovered, value)
+	}
+	ts := httptest.NewServer(router)
+
+	cases := []Case{
+		Case{ // MyApi без NewMyApi падает на nil мьютексе
+			Path:   "/my" + ApiUserProfile,
+			Query:  "login=rvasily",
+			Status: http.StatusInternalServerError,
+			Result: CR{
+				"error": "internal error",
+			},
+		},
+		Case{ // после авторизации и валидации Create падает на том же мьютексе
+			Path:   "/my" + ApiUserCreate,
+			Method: http.MethodPost,
+			Query:  "login=mr.moderator&age=32&status=moderator&full_name=Ivan_Ivanov",
+			Status: http.StatusInternalServerError,
+			Auth:   true,
+			Result: CR{
+				"error": "internal error",
+			},
+		},
+	}
+
+	runTests(t, ts, cases)
+
+	if len(recovered) != 2 {
+		t.Errorf("expected two panics to be reported, got %v", recovered)
+	}
+}
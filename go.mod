@@ -1,3 +1,3 @@
 module codegenhw
 
-go 1.20
+go 1.22
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+	"text/template"
+)
+
+type endpointTempl struct {
+	GeneratedFunc
+	VarName string
+}
+
+// endpointTemplate describes the handler for metrics, access logs and traces
+var endpointTemplate = template.Must(template.New("endpointTempl").Parse(`var {{.VarName}} = &ApiEndpoint{
+	Receiver:   "{{.ReceiverTypeName}}",
+	Method:     "{{.FuncName}}",
+	HTTPMethod: "{{.HttpMethod}}",
+	Route:      "{{.Url}}",
+	Auth:       {{.Auth}},
+	{{- if .Roles}}
+	Roles:      []string{ {{- range $i, $role := .Roles}}{{if $i}}, {{end}}{{printf "%q" $role}}{{end -}} },
+	{{- end}}
+	{{- if .Stream}}
+	Stream:     "{{.Stream}}",
+	{{- end}}
+	{{- if .Timeout}}
+	Timeout:    "{{.Timeout}}",
+	{{- end}}
+	{{- if .RateLimit}}
+	RateLimit:  {{.RateLimitExpr}},
+	{{- end}}
+}
+
+`))
+
+func endpointVarName(f GeneratedFunc) string {
+	return "apiEndpoint" + f.ReceiverTypeName + f.FuncName
+}
+
+// hasMethod is true when the file declares method name of typeName
+func hasMethod(node *ast.File, typeName, name string) bool {
+	for _, decl := range node.Decls {
+		f, ok := decl.(*ast.FuncDecl)
+		if ok && f.Recv != nil && f.Name.Name == name && resultTypeName(f.Recv.List[0].Type) == typeName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generateLogValues emits slog.LogValuer for params structs, fields with the
+// "sensitive" apivalidator option are redacted
+func generateLogValues(out io.Writer, node *ast.File, structs []GeneratedStruct) {
+	for _, genStruct := range structs {
+		if hasMethod(node, genStruct.Name, "LogValue") {
+			continue
+		}
+
+		fmt.Fprintf(out, "\n// LogValue hides sensitive %s fields in access logs\n", genStruct.Name)
+		fmt.Fprintf(out, "func (p %s) LogValue() slog.Value {\n", genStruct.Name)
+		fmt.Fprintln(out, "\treturn slog.GroupValue(")
+		for _, attr := range genStruct.Attributes {
+			name := attr.LogName()
+			switch {
+			case attr.Sensitive.Exist:
+				fmt.Fprintf(out, "\t\tslog.String(%q, \"[REDACTED]\"),\n", name)
+			case attr.FieldType == "string":
+				fmt.Fprintf(out, "\t\tslog.String(%q, p.%s),\n", name, attr.FieldName)
+			case attr.FieldType == "int":
+				fmt.Fprintf(out, "\t\tslog.Int(%q, p.%s),\n", name, attr.FieldName)
+			default:
+				fmt.Fprintf(out, "\t\tslog.Any(%q, p.%s),\n", name, attr.FieldName)
+			}
+		}
+		fmt.Fprintln(out, "\t)")
+		fmt.Fprintln(out, "}")
+	}
+}
+
+// accessLogTemplate writes one slog record per request when
+// ApiConfig.AccessLogger is set
+var accessLogTemplate = template.Must(template.New("accessLogTempl").Parse(`
+// ApiEndpoint describes a generated handler
+type ApiEndpoint struct {
+	Receiver   string
+	Method     string
+	HTTPMethod string
+	Route      string
+	Auth       bool
+	Roles      []string
+	Stream     string
+	Timeout    string
+{{- if .RateLimit}}
+	RateLimit  *ApiRateLimit
+{{- end}}
+}
+
+type apiRequestInfoCtxKey struct{}
+
+// apiRequestInfo is filled while the request is handled and logged at the end
+type apiRequestInfo struct {
+	requestID string
+	principal string
+	params    any
+}
+
+func requestInfo(r *http.Request) *apiRequestInfo {
+	info, _ := r.Context().Value(apiRequestInfoCtxKey{}).(*apiRequestInfo)
+	return info
+}
+
+// RequestIDFromContext returns X-Request-ID of the request or the generated one
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	info, ok := ctx.Value(apiRequestInfoCtxKey{}).(*apiRequestInfo)
+	if !ok {
+		return "", false
+	}
+	return info.requestID, true
+}
+
+func newRequestID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+func (cfg *ApiConfig) logAccess(r *http.Request, endpoint *ApiEndpoint, info *apiRequestInfo, status int, latency time.Duration) {
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("route", endpoint.Route),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", status),
+		slog.Duration("latency", latency),
+		slog.String("request_id", info.requestID),
+	}
+	if info.principal != "" {
+		attrs = append(attrs, slog.String("principal", info.principal))
+	}
+	if info.params != nil {
+		attrs = append(attrs, slog.Any("params", info.params))
+	}
+
+	cfg.AccessLogger.LogAttrs(r.Context(), level, endpoint.Receiver+"."+endpoint.Method, attrs...)
+}
+`))
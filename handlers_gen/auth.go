@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"text/template"
+)
+
+// knownAuthSchemes may be used as "auth": "<scheme>", "auth": true and
+// "token" use ApiConfig.Authenticator
+var knownAuthSchemes = []string{"token", "jwt", "basic", "apikey", "cookie"}
+
+// apiGenAuth accepts "auth" as a bool, a scheme name or a list of schemes
+// tried in order
+type apiGenAuth struct {
+	Enabled bool
+	Schemes []string
+}
+
+func (a *apiGenAuth) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Enabled); err == nil {
+		return nil
+	}
+
+	var scheme string
+	if err := json.Unmarshal(data, &scheme); err == nil {
+		a.Enabled = true
+		a.Schemes = []string{scheme}
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &a.Schemes); err != nil || len(a.Schemes) == 0 {
+		return fmt.Errorf("auth must be bool, scheme name or list of schemes, got %s", data)
+	}
+	a.Enabled = true
+
+	return nil
+}
+
+func checkAuthSchemes(funcs []GeneratedFunc) error {
+	for _, f := range funcs {
+		for _, scheme := range f.AuthSchemes {
+			if !slices.Contains(knownAuthSchemes, scheme) {
+				return fmt.Errorf("%s.%s: unknown auth scheme %q, expected one of %v", f.ReceiverTypeName, f.FuncName, scheme, knownAuthSchemes)
+			}
+		}
+	}
+
+	return nil
+}
+
+type checkRolesTempl struct {
+	Roles []string
+}
+
+var checkRolesTemplate = template.Must(template.New("checkRolesTempl").Parse(`
+	// check roles
+	if !hasAnyRole(ctx{{range .Roles}}, "{{.}}"{{end}}) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+`))
+
+// resolveRoles expands min_role into the list of roles allowed to call the
+// method, ranking lists roles from the lowest to the highest
+func resolveRoles(funcs []GeneratedFunc, ranking []string) error {
+	for i, f := range funcs {
+		if f.MinRole != "" {
+			rank := slices.Index(ranking, f.MinRole)
+			if rank < 0 {
+				return fmt.Errorf("%s.%s: min_role %q is not one of -roles %v", f.ReceiverTypeName, f.FuncName, f.MinRole, ranking)
+			}
+
+			for _, role := range ranking[rank:] {
+				if !slices.Contains(f.Roles, role) {
+					funcs[i].Roles = append(funcs[i].Roles, role)
+				}
+			}
+		}
+
+		if len(funcs[i].Roles) > 0 && !f.Auth {
+			return fmt.Errorf("%s.%s: roles need \"auth\": true", f.ReceiverTypeName, f.FuncName)
+		}
+	}
+
+	return nil
+}
+
+var authRuntime = `
+// Principal is the authenticated caller, api methods get it via PrincipalFromContext
+type Principal struct {
+	ID    string
+	Roles []string
+}
+
+type principalCtxKey struct{}
+
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalCtxKey{}).(Principal)
+	return principal, ok
+}
+
+var errUnauthorized = errors.New("unauthorized")
+
+// hasAnyRole reports whether the authenticated principal has one of roles
+func hasAnyRole(ctx context.Context, roles ...string) bool {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	for _, role := range principal.Roles {
+		if slices.Contains(roles, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authenticator checks the request and returns its context with the Principal attached
+type Authenticator interface {
+	Authenticate(r *http.Request) (context.Context, error)
+}
+
+// AuthenticatorFunc adapts a function to Authenticator
+type AuthenticatorFunc func(r *http.Request) (context.Context, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (context.Context, error) {
+	return f(r)
+}
+
+// TokenAuthenticator accepts requests carrying one of the known Tokens in Header
+type TokenAuthenticator struct {
+	Header string
+	Tokens map[string]Principal
+}
+
+func (a TokenAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	token := r.Header.Get(a.Header)
+	principal, ok := a.Tokens[token]
+	if token == "" || !ok {
+		return nil, errUnauthorized
+	}
+
+	return ContextWithPrincipal(r.Context(), principal), nil
+}
+`
+
+// basicAuthRuntime, apiKeyAuthRuntime and cookieAuthRuntime are emitted for
+// endpoints using their schemes, verifiedContext is shared by them
+var basicAuthRuntime = `
+// BasicAuthenticator checks "Authorization: Basic" credentials
+type BasicAuthenticator struct {
+	Verify func(ctx context.Context, user, password string) (Principal, error)
+}
+
+func (a BasicAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	user, password, ok := r.BasicAuth()
+	if !ok || a.Verify == nil {
+		return nil, errUnauthorized
+	}
+
+	principal, err := a.Verify(r.Context(), user, password)
+	return verifiedContext(r, principal, err)
+}
+`
+
+var apiKeyAuthRuntime = `
+// APIKeyAuthenticator takes the key from Header, then from Query parameter
+type APIKeyAuthenticator struct {
+	Header string
+	Query  string
+	Verify func(ctx context.Context, key string) (Principal, error)
+}
+
+func (a APIKeyAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	key := ""
+	if a.Header != "" {
+		key = r.Header.Get(a.Header)
+	}
+	if key == "" && a.Query != "" {
+		key = r.URL.Query().Get(a.Query)
+	}
+	if key == "" || a.Verify == nil {
+		return nil, errUnauthorized
+	}
+
+	principal, err := a.Verify(r.Context(), key)
+	return verifiedContext(r, principal, err)
+}
+`
+
+var cookieAuthRuntime = `
+// CookieAuthenticator checks the session stored in the cookie Name
+type CookieAuthenticator struct {
+	Name   string
+	Verify func(ctx context.Context, session string) (Principal, error)
+}
+
+func (a CookieAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	cookie, err := r.Cookie(a.Name)
+	if err != nil || cookie.Value == "" || a.Verify == nil {
+		return nil, errUnauthorized
+	}
+
+	principal, err := a.Verify(r.Context(), cookie.Value)
+	return verifiedContext(r, principal, err)
+}
+`
+
+var verifiedContextRuntime = `
+func verifiedContext(r *http.Request, principal Principal, err error) (context.Context, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return ContextWithPrincipal(r.Context(), principal), nil
+}
+`
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// bodyUnits are suffixes of "max_body", KB is 1024 bytes like KiB
+var bodyUnits = []struct {
+	Suffix string
+	Shift  int
+}{
+	{"KIB", 10}, {"MIB", 20}, {"GIB", 30},
+	{"KB", 10}, {"MB", 20}, {"GB", 30},
+	{"B", 0},
+}
+
+// parseBodySize parses sizes like "1MB", "512KB" or "100"
+func parseBodySize(size string) (int64, error) {
+	number, shift := strings.ToUpper(strings.TrimSpace(size)), 0
+	for _, unit := range bodyUnits {
+		if trimmed, ok := strings.CutSuffix(number, unit.Suffix); ok {
+			number, shift = strings.TrimSpace(trimmed), unit.Shift
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad size %q", size)
+	}
+	if n <= 0 || n > (1<<62)>>shift {
+		return 0, fmt.Errorf("size must be positive, got %q", size)
+	}
+
+	return n << shift, nil
+}
+
+// formMediaTypes are the bodies the generated handlers read with FormValue,
+// "consumes" may only narrow them down
+var formMediaTypes = []string{"application/x-www-form-urlencoded", "multipart/form-data"}
+
+// checkBodies validates "max_body" sizes and "consumes" media types
+func checkBodies(funcs []GeneratedFunc) error {
+	for i, f := range funcs {
+		if f.MaxBody != "" {
+			if _, err := parseBodySize(f.MaxBody); err != nil {
+				return fmt.Errorf("%s.%s: max_body: %w", f.ReceiverTypeName, f.FuncName, err)
+			}
+		}
+
+		for j, consumes := range f.Consumes {
+			mediaType, params, err := mime.ParseMediaType(consumes)
+			if err != nil || len(params) > 0 || strings.Contains(mediaType, "*") {
+				return fmt.Errorf("%s.%s: consumes %q is not a media type", f.ReceiverTypeName, f.FuncName, consumes)
+			}
+			if !slices.Contains(formMediaTypes, mediaType) {
+				return fmt.Errorf("%s.%s: consumes %q, params are read only from %s", f.ReceiverTypeName, f.FuncName, consumes, strings.Join(formMediaTypes, " and "))
+			}
+			funcs[i].Consumes[j] = mediaType
+		}
+	}
+
+	return nil
+}
+
+// MaxBodyExpr is the "max_body" annotation as a Go expression, e.g. 1 << 20
+func (f GeneratedFunc) MaxBodyExpr() string {
+	if f.MaxBody == "" {
+		return "0"
+	}
+
+	size, _ := parseBodySize(f.MaxBody)
+	for _, shift := range []int{30, 20, 10} {
+		if size%(1<<shift) == 0 {
+			return fmt.Sprintf("%d << %d", size>>shift, shift)
+		}
+	}
+
+	return strconv.FormatInt(size, 10)
+}
+
+type checkBodyTempl struct {
+	MaxBody  string
+	Consumes []string
+}
+
+var checkBodyTemplate = template.Must(template.New("checkBodyTempl").Parse(`
+	// check body
+	if !checkBody(w, r, {{.MaxBody}}{{range .Consumes}}, "{{.}}"{{end}}) {
+		return
+	}
+`))
+
+var bodyRuntime = `
+// checkBody answers 415 when a request with a body is not one of consumes
+// media types and parses the form reading at most maxBody bytes, a larger
+// body is answered with 413
+func checkBody(w http.ResponseWriter, r *http.Request, maxBody int64, consumes ...string) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if len(consumes) > 0 && r.ContentLength != 0 && !slices.Contains(consumes, mediaType) {
+		writeError(w, r, http.StatusUnsupportedMediaType, "unsupported media type")
+		return false
+	}
+
+	if maxBody <= 0 {
+		return true
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+
+	var err error
+	if mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(maxBody)
+	} else {
+		err = r.ParseForm()
+	}
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+		return false
+	case err != nil:
+		writeError(w, r, http.StatusBadRequest, "bad request body")
+		return false
+	}
+
+	return true
+}
+`
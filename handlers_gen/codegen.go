@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -11,6 +13,8 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -21,16 +25,28 @@ type serveHTTPTempl struct {
 	GeneratedFuncs   []GeneratedFunc
 }
 
+type receiverHandlerTempl struct {
+	ReceiverTypeName string
+	Mux              bool
+}
+
 type checkMethodTempl struct {
 	HttpMethod string
 }
 
+type checkAuthTempl struct {
+	Schemes []string
+}
+
 type responseTempl struct {
 	FuncName string
+	Envelope string
+	Status   string
 }
 
 type baseValidationTempl struct {
 	FieldName string
+	ParamName string
 	VarName   string
 }
 
@@ -67,46 +83,82 @@ type validationCastToIntTempl struct {
 
 var (
 	serveHTTPTemplate = template.Must(template.New("serveHTTPTempl").Parse(`
-	// {{.ReceiverTypeName}}
+// ServeHTTP serves {{.ReceiverTypeName}} with DefaultApiConfig
 func (h *{{.ReceiverTypeName}}) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.serveApi(w, r, DefaultApiConfig)
+}
+
+func (h *{{.ReceiverTypeName}}) serveApi(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
 	switch r.URL.Path {
 		{{range $val := .GeneratedFuncs}}
 		case "{{$val.Url}}":
-			h.handler{{$val.FuncName}}(w, r)
+			h.handler{{$val.FuncName}}(w, r, cfg)
 		{{end}}
 		default:
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, r, http.StatusNotFound, "unknown method")
 	}
 }
 
+`))
+
+	receiverHandlerTemplate = template.Must(template.New("receiverHandlerTempl").Parse(`
+// {{.ReceiverTypeName}}Handler serves {{.ReceiverTypeName}} with Config, DefaultApiConfig when nil
+type {{.ReceiverTypeName}}Handler struct {
+	Api    *{{.ReceiverTypeName}}
+	Config *ApiConfig
+}
+
+func New{{.ReceiverTypeName}}Handler(api *{{.ReceiverTypeName}}, cfg *ApiConfig) *{{.ReceiverTypeName}}Handler {
+	return &{{.ReceiverTypeName}}Handler{Api: api, Config: cfg}
+}
+
+func (h *{{.ReceiverTypeName}}Handler) apiConfig() *ApiConfig {
+	if h.Config == nil {
+		return DefaultApiConfig
+	}
+
+	return h.Config
+}
+{{if .Mux}}
+// Register adds the routes of {{.ReceiverTypeName}} to mux bound to the current Config
+func (h *{{.ReceiverTypeName}}Handler) Register(mux *http.ServeMux) {
+	h.Api.register(mux, h.apiConfig())
+}
+{{else}}
+func (h *{{.ReceiverTypeName}}Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Api.serveApi(w, r, h.apiConfig())
+}
+{{end}}
 `))
 
 	checkMethodTemplate = template.Must(template.New("checkMethodTempl").Parse(`
 	// check http method
 	method := "{{.HttpMethod}}"
 	if r.Method != method {
-		w.WriteHeader(http.StatusNotAcceptable)
-		w.Write(getErrorResponse("bad method"))
+		writeError(w, r, http.StatusNotAcceptable, "bad method")
 		return
 	}
 	`))
 
 	checkAuthTemplate = template.Must(template.New("checkAuthTempl").Parse(`
 	// check auth
-	token := r.Header.Get("X-Auth")
-	if !checkToken(token) {
-		w.WriteHeader(http.StatusForbidden)
-		w.Write(getErrorResponse("unathorized"))
+	ctx, err := cfg.authenticate(r{{range .Schemes}}, "{{.}}"{{end}})
+	if err != nil {
+		writeError(w, r, http.StatusForbidden, "unauthorized")
 		return
 	}
-	`))
+	if info := requestInfo(r); info != nil {
+		principal, _ := PrincipalFromContext(ctx)
+		info.principal = principal.ID
+	}
+`))
 
 	validationCastToIntTemplate = template.Must(template.New("validationCastToIntTempl").Parse(`
 	// cast to int
 	{{.VarName}}, err := strconv.Atoi({{.FieldName}})
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(getErrorResponse("{{.FieldName}} must be int"))
+		writeError(w, r, http.StatusBadRequest, "{{.FieldName}} must be int", "{{.ParamName}}")
+		return
 	}
 	`))
 
@@ -127,8 +179,7 @@ func (h *{{.ReceiverTypeName}}) ServeHTTP(w http.ResponseWriter, r *http.Request
 	validationMinMaxTemplate = template.Must(template.New("validationMinMaxTempl").Parse(`
 	// {{if $.IsMin}}min{{else}}max{{end}}
 	if {{ if $.WithLen }}len({{.VarName}}){{ else }}{{.VarName}}{{ end }} {{ if $.IsMin }}< {{ else }}> {{ end }}{{$.Value}} {
-    	w.WriteHeader(http.StatusBadRequest)
-    	w.Write(getErrorResponse("{{.FieldName}}{{if $.WithLen}} len{{end}} must be {{if $.IsMin}}>= {{else}}<= {{end}}{{$.Value}}"))
+    	writeError(w, r, http.StatusBadRequest, "{{.FieldName}}{{if $.WithLen}} len{{end}} must be {{if $.IsMin}}>= {{else}}<= {{end}}{{$.Value}}", "{{.ParamName}}")
     	return
 	}
 	`))
@@ -136,8 +187,7 @@ func (h *{{.ReceiverTypeName}}) ServeHTTP(w http.ResponseWriter, r *http.Request
 	validationRequiredTemplate = template.Must(template.New("validationRequiredTempl").Parse(`
 	// required
 	if {{.VarName}} == {{.EmptyValue}} {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(getErrorResponse("{{.FieldName}} must me not empty"))
+		writeError(w, r, http.StatusBadRequest, "{{.FieldName}} must me not empty", "{{.ParamName}}")
 		return
 	}
 	`))
@@ -150,41 +200,35 @@ func (h *{{.ReceiverTypeName}}) ServeHTTP(w http.ResponseWriter, r *http.Request
         	{{$.VarName}} == "{{$v}}"
     	{{- end -}}
 	) {
-    	w.WriteHeader(http.StatusBadRequest)
-    	w.Write(getErrorResponse("{{.FieldName}} must be one of [
+    	writeError(w, r, http.StatusBadRequest, "{{.FieldName}} must be one of [
         	{{- range $i, $v := .Enum -}}
             	{{- if gt $i 0}}, {{end -}}
             	{{$v}}
         	{{- end -}}
-    	]"))
+    	]", "{{.ParamName}}")
     	return
 	}
 	`))
 
 	responseTemplate = template.Must(template.New("responseTempl").Parse(`
-	ctx := context.Background()
-
-	result := response{}
-
-	resp, err := h.{{.FuncName}}(ctx, params)
+	callCtx, callPhase := cfg.startPhase(ctx, "call")
+	resp, err := h.{{.FuncName}}(callCtx, params)
+	callPhase.end(err)
 	if err != nil {
-		apiError := err.(ApiError)
-
-		w.WriteHeader(apiError.HTTPStatus)
-		result.Error = apiError.Error()
-
-		data, _ := json.Marshal(result)
-		w.Write(data)
+		status, message := cfg.errorResponse(r, err)
+		writeError(w, r, status, message)
 		return
 	}
 
-	data, _ := json.Marshal(resp)
-	result.Response = data
-
-	data, _ = json.Marshal(result)
-	
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	_, encodePhase := cfg.startPhase(ctx, "encode")
+{{- if eq .Envelope "classic"}}
+	writeResult(w, r, {{.Status}}, resp, response{Response: resp})
+	{{- else if eq .Envelope "bare"}}
+	writeResult(w, r, {{.Status}}, resp, resp)
+	{{- else}}
+	writeResult(w, r, {{.Status}}, resp, wrap{{.Envelope}}(resp))
+	{{- end}}
+	encodePhase.end(nil)
 	`))
 )
 
@@ -196,12 +240,25 @@ var (
 	validatorMin       = "min"
 	validatorMax       = "max"
 	validatorDefault   = "default"
+	validatorSensitive = "sensitive"
 )
 
 type ApiGenApi struct {
-	Url    string `json:"url"`
-	Auth   bool   `json:"auth"`
-	Method string `json:"method"`
+	Url        string     `json:"url"`
+	Auth       apiGenAuth `json:"auth"`
+	Method     string     `json:"method"`
+	Roles      []string   `json:"roles"`
+	Stream     string     `json:"stream"`
+	Status     int        `json:"status"`
+	Timeout    string     `json:"timeout"`
+	MinRole    string     `json:"min_role"`
+	Middleware []string   `json:"middleware"`
+
+	RateLimit *apiGenRateLimit `json:"ratelimit"`
+	MaxBody   string           `json:"max_body"`
+	Consumes  []string         `json:"consumes"`
+
+	Idempotent bool `json:"idempotent"`
 }
 
 type GeneratedFunc struct {
@@ -212,9 +269,41 @@ type GeneratedFunc struct {
 	InTypeName string // remove?
 	In         *ast.Field
 
-	Url    string
-	Auth   bool
-	Method string
+	Url         string
+	Auth        bool
+	AuthSchemes []string
+	Method      string
+	Roles       []string
+	MinRole     string
+	Status      int
+	Timeout     string
+	Middleware  []string
+	Envelope    string
+	RateLimit   *apiGenRateLimit
+	MaxBody     string
+	Consumes    []string
+	Idempotent  bool
+
+	// ResultTypeName is the struct returned by the method, empty when it is
+	// not a type declared in the parsed file
+	ResultTypeName string
+
+	// Stream is ndjson or sse for methods returning a channel or taking a sink
+	Stream         string
+	StreamItemType string
+	StreamSink     bool
+}
+
+// HttpMethod returns the method the handler accepts, empty means any
+func (f GeneratedFunc) HttpMethod() string {
+	if f.Method == "" {
+		return ""
+	}
+	if f.Method == http.MethodGet {
+		return http.MethodGet
+	}
+
+	return http.MethodPost
 }
 
 type ValidatorValue[T any] struct {
@@ -239,6 +328,16 @@ type GeneratedParamsField struct {
 	ParamName    ValidatorValue[string]
 	Min          ValidatorValue[int]
 	Max          ValidatorValue[int]
+	Sensitive    ValidatorValue[bool]
+}
+
+// LogName is the request param name of the field
+func (f GeneratedParamsField) LogName() string {
+	if f.ParamName.Exist {
+		return f.ParamName.Value
+	}
+
+	return strings.ToLower(f.FieldName)
 }
 
 type GeneratedStruct struct {
@@ -279,6 +378,10 @@ func getGeneratedParamsField(tagValue string) GeneratedParamsField {
 			params.Enum = NewValidatorValue(values)
 		}
 
+		if field == validatorSensitive {
+			params.Sensitive = NewValidatorValue(true)
+		}
+
 		if field == validatorDefault {
 			val := getValidatorValue(v)
 			params.DefaultValue = NewValidatorValue(val)
@@ -316,7 +419,7 @@ func getGeneratedFuncs(node *ast.File) []GeneratedFunc {
 		f, ok := decl.(*ast.FuncDecl)
 
 		if !ok {
-			fmt.Printf("SKIP %#T is not &ast.FuncDecl\n", decl)
+			fmt.Printf("SKIP %T is not &ast.FuncDecl\n", decl)
 			continue
 		}
 
@@ -346,11 +449,22 @@ func getGeneratedFuncs(node *ast.File) []GeneratedFunc {
 		}
 
 		generatedFunc := GeneratedFunc{
-			Method:   apiGen.Method,
-			Url:      apiGen.Url,
-			Auth:     apiGen.Auth,
-			FuncName: f.Name.Name,
-			Receiver: f.Recv,
+			Method:      apiGen.Method,
+			Url:         apiGen.Url,
+			Auth:        apiGen.Auth.Enabled,
+			AuthSchemes: apiGen.Auth.Schemes,
+			Roles:       apiGen.Roles,
+			MinRole:     apiGen.MinRole,
+			Stream:      apiGen.Stream,
+			Status:      apiGen.Status,
+			Timeout:     apiGen.Timeout,
+			Middleware:  apiGen.Middleware,
+			RateLimit:   apiGen.RateLimit,
+			MaxBody:     apiGen.MaxBody,
+			Consumes:    apiGen.Consumes,
+			Idempotent:  apiGen.Idempotent,
+			FuncName:    f.Name.Name,
+			Receiver:    f.Recv,
 		}
 
 		for _, field := range f.Recv.List {
@@ -361,12 +475,19 @@ func getGeneratedFuncs(node *ast.File) []GeneratedFunc {
 		}
 
 		for index, param := range f.Type.Params.List {
-			if index > 0 {
+			if index == 1 {
 				generatedFunc.In = param
 				generatedFunc.InTypeName = param.Type.(*ast.Ident).Name
 			}
 		}
 
+		generatedFunc.StreamItemType, generatedFunc.StreamSink = streamSignature(f)
+		if generatedFunc.StreamItemType != "" {
+			generatedFunc.ResultTypeName = resultTypeName(streamItemExpr(f, generatedFunc.StreamSink))
+		} else if f.Type.Results != nil && len(f.Type.Results.List) > 0 {
+			generatedFunc.ResultTypeName = resultTypeName(f.Type.Results.List[0].Type)
+		}
+
 		genFuncs = append(genFuncs, generatedFunc)
 	}
 
@@ -381,7 +502,7 @@ func getGeneratedStructs(node *ast.File) []GeneratedStruct {
 		g, ok := decl.(*ast.GenDecl)
 
 		if !ok {
-			fmt.Printf("SKIP %#T is not &ast.GenDecl\n", decl)
+			fmt.Printf("SKIP %T is not &ast.GenDecl\n", decl)
 			continue
 		}
 
@@ -393,13 +514,13 @@ func getGeneratedStructs(node *ast.File) []GeneratedStruct {
 		for _, spec := range g.Specs {
 			currType, ok := spec.(*ast.TypeSpec)
 			if !ok {
-				fmt.Printf("SKIP %#T is not ast.TypeSpec\n", spec)
+				fmt.Printf("SKIP %T is not ast.TypeSpec\n", spec)
 				continue
 			}
 
 			currStruct, ok := currType.Type.(*ast.StructType)
 			if !ok {
-				fmt.Printf("SKIP %#T is not ast.StructType\n", currStruct)
+				fmt.Printf("SKIP %T is not ast.StructType\n", currStruct)
 				continue
 			}
 
@@ -447,29 +568,152 @@ func getGeneratedStructs(node *ast.File) []GeneratedStruct {
 	return genStructs
 }
 
-func writeImports(out io.Writer) {
-	fmt.Fprintln(out, `
-import (
-	"context"
-	"encoding/json"
-	"net/http"
-	"strconv"
-)`)
+// importPaths lists packages the runtime and generated handlers may refer to
+var importPaths = map[string]string{
+	"bytes": "bytes", "list": "container/list", "context": "context", "hmac": "crypto/hmac",
+	"rand": "crypto/rand", "sha256": "crypto/sha256", "sha512": "crypto/sha512", "encoding": "encoding",
+	"base64": "encoding/base64", "hex": "encoding/hex", "json": "encoding/json", "xml": "encoding/xml",
+	"errors": "errors", "fmt": "fmt", "hash": "hash", "io": "io", "log": "log", "slog": "log/slog",
+	"math": "math", "mime": "mime", "net": "net", "http": "net/http", "reflect": "reflect",
+	"debug": "runtime/debug", "slices": "slices", "strconv": "strconv", "strings": "strings",
+	"sync": "sync", "time": "time", "utf8": "unicode/utf8",
+}
+
+// writeImports imports packages the emitted code refers to
+func writeImports(out io.Writer, code []byte) error {
+	file, err := parser.ParseFile(token.NewFileSet(), "", append([]byte("package generated\n"), code...), 0)
+	if err != nil {
+		return err
+	}
+
+	imports := []string{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		pkg, ok := selector.X.(*ast.Ident)
+		if !ok || pkg.Obj != nil {
+			return true
+		}
+		if path, ok := importPaths[pkg.Name]; ok && !slices.Contains(imports, path) {
+			imports = append(imports, path)
+		}
+		return true
+	})
+	sort.Strings(imports)
+
+	fmt.Fprintln(out, "\nimport (")
+	for _, imp := range imports {
+		fmt.Fprintf(out, "\t%q\n", imp)
+	}
+	fmt.Fprintln(out, ")")
+
+	return nil
+}
+
+// runtimeUsage tells which parts of the runtime the endpoints and flags need
+type runtimeUsage struct {
+	Schemes     map[string]bool
+	Stream      bool
+	RateLimit   bool
+	Body        bool
+	Idempotency bool
+	Radix       bool
+	Problem     bool
+	// Prometheus and RecordingTracer are asked for by flags
+	Prometheus      bool
+	RecordingTracer bool
 }
 
-func writeUtils(out io.Writer) {
-	fmt.Fprintln(out, "type response struct {\n\tResponse json.RawMessage `json:\"response\"`\n\tError    string          `json:\"error\"`\n}\n\nfunc checkToken(token string) bool {\n\treturn token == \"100500\"\n}\n\nfunc getErrorResponse(err string) []byte {\n\tdata, _ := json.Marshal(response{\n\t\tError: err,\n\t})\n\n\treturn data\n}")
+func getRuntimeUsage(funcs []GeneratedFunc, routing, errorsFormat string) runtimeUsage {
+	usage := runtimeUsage{
+		Schemes: map[string]bool{},
+		Radix:   routing == routingRadix,
+		Problem: errorsFormat == errorsProblem,
+	}
+	for _, f := range funcs {
+		for _, scheme := range f.AuthSchemes {
+			usage.Schemes[scheme] = true
+		}
+		usage.Stream = usage.Stream || f.Stream != ""
+		usage.RateLimit = usage.RateLimit || f.RateLimit != nil
+		usage.Body = usage.Body || f.MaxBody != "" || len(f.Consumes) > 0
+		usage.Idempotency = usage.Idempotency || f.Idempotent
+	}
+
+	return usage
+}
+
+// writeUtils emits the runtime, blocks of features no endpoint uses are left out
+func writeUtils(out io.Writer, usage runtimeUsage) {
+	configTemplate.Execute(out, usage)
+	fmt.Fprint(out, authRuntime)
+	if usage.Schemes["jwt"] {
+		fmt.Fprint(out, jwtRuntime)
+	}
+	if usage.Schemes["basic"] {
+		fmt.Fprint(out, basicAuthRuntime)
+	}
+	if usage.Schemes["apikey"] {
+		fmt.Fprint(out, apiKeyAuthRuntime)
+	}
+	if usage.Schemes["cookie"] {
+		fmt.Fprint(out, cookieAuthRuntime)
+	}
+	if usage.Schemes["basic"] || usage.Schemes["apikey"] || usage.Schemes["cookie"] {
+		fmt.Fprint(out, verifiedContextRuntime)
+	}
+	fmt.Fprint(out, responseRuntime)
+	fmt.Fprint(out, jsonRuntime)
+	fmt.Fprint(out, negotiationRuntime)
+	if usage.Stream {
+		fmt.Fprint(out, streamRuntime)
+	}
+	fmt.Fprint(out, statusRuntime)
+	fmt.Fprint(out, metricsRuntime)
+	if usage.Prometheus {
+		fmt.Fprint(out, prometheusRuntime)
+	}
+	accessLogTemplate.Execute(out, usage)
+	fmt.Fprint(out, tracingRuntime)
+	if usage.RecordingTracer {
+		fmt.Fprint(out, recordingTracerRuntime)
+	}
+	if usage.RateLimit {
+		fmt.Fprint(out, rateLimitRuntime)
+	}
+	if usage.Body {
+		fmt.Fprint(out, bodyRuntime)
+	}
+	if usage.Idempotency {
+		fmt.Fprint(out, idempotencyRuntime)
+	}
+	if usage.Radix {
+		fmt.Fprint(out, radixRuntime)
+	}
+
+	if usage.Problem {
+		fmt.Fprint(out, problemErrorRuntime)
+	} else {
+		fmt.Fprint(out, classicErrorRuntime)
+	}
 }
 
-func generateValidationCode(out io.Writer, genStruct *GeneratedStruct) {
+func generateValidationCode(out io.Writer, genStruct *GeneratedStruct, pathParams []string) {
 	for _, attr := range genStruct.Attributes {
 
 		fieldName := strings.ToLower(attr.FieldName)
 		paramName := fieldName
 		fieldNameInt := fieldName + "Int"
 
+		if attr.ParamName.Exist {
+			paramName = attr.ParamName.Value
+		}
+
 		baseValidation := baseValidationTempl{
 			FieldName: fieldName,
+			ParamName: paramName,
 			VarName:   fieldName,
 		}
 
@@ -477,12 +721,12 @@ func generateValidationCode(out io.Writer, genStruct *GeneratedStruct) {
 			baseValidation.VarName = fieldNameInt
 		}
 
-		if attr.ParamName.Exist {
-			paramName = attr.ParamName.Value
+		if slices.Contains(pathParams, paramName) {
+			fmt.Fprintf(out, "\n	%v := r.PathValue(\"%v\")\n", fieldName, paramName)
+		} else {
+			fmt.Fprintf(out, "\n	%v := r.FormValue(\"%v\")\n", fieldName, paramName)
 		}
 
-		fmt.Fprintf(out, "\n	%v := r.FormValue(\"%v\")\n", fieldName, paramName)
-
 		if attr.FieldType == "int" {
 			validationCastToIntTemplate.Execute(out, validationCastToIntTempl{
 				baseValidationTempl: baseValidation,
@@ -528,7 +772,7 @@ func generateValidationCode(out io.Writer, genStruct *GeneratedStruct) {
 			validationMinMaxTemplate.Execute(out, validationMinMaxTempl{
 				baseValidationTempl: baseValidation,
 				WithLen:             attr.FieldType == "string",
-				Value:               attr.Max.Value,
+				Value:               attr.Min.Value,
 				IsMin:               true,
 			})
 		}
@@ -553,38 +797,119 @@ func generateValidationCode(out io.Writer, genStruct *GeneratedStruct) {
 	}
 }
 
-func generateCode(out io.Writer, funcs []GeneratedFunc, structs []GeneratedStruct) error {
+func groupFuncsByReceiver(funcs []GeneratedFunc) ([]string, map[string][]GeneratedFunc) {
+	receivers := make([]string, 0)
 	funcsMap := make(map[string][]GeneratedFunc, len(funcs))
 
 	for _, f := range funcs {
+		if _, exist := funcsMap[f.ReceiverTypeName]; !exist {
+			receivers = append(receivers, f.ReceiverTypeName)
+		}
 		funcsMap[f.ReceiverTypeName] = append(funcsMap[f.ReceiverTypeName], f)
 	}
 
-	for k, v := range funcsMap {
-		serveHTTPTemplate.Execute(out, serveHTTPTempl{
+	return receivers, funcsMap
+}
+
+func generateCode(out io.Writer, funcs []GeneratedFunc, structs []GeneratedStruct, routing string) error {
+	receivers, funcsMap := groupFuncsByReceiver(funcs)
+
+	for _, k := range receivers {
+		v := funcsMap[k]
+
+		switch routing {
+		case routingRadix:
+			radixServeHTTPTemplate.Execute(out, receiverRadixTempl(k, v))
+		case routingMux:
+			muxRegisterTemplate.Execute(out, receiverMuxTempl(k, v))
+		default:
+			serveHTTPTemplate.Execute(out, serveHTTPTempl{
+				ReceiverTypeName: k,
+				GeneratedFuncs:   v,
+			})
+		}
+		receiverHandlerTemplate.Execute(out, receiverHandlerTempl{
 			ReceiverTypeName: k,
-			GeneratedFuncs:   v,
+			Mux:              routing == routingMux,
 		})
 
 		for _, f := range v {
-			fmt.Fprintf(out, "func (h *%s) handler%s(w http.ResponseWriter, r *http.Request) {\n", k, f.FuncName)
+			endpointTemplate.Execute(out, endpointTempl{GeneratedFunc: f, VarName: endpointVarName(f)})
+
+			handlerName := "handler" + f.FuncName
+			if len(f.Middleware) > 0 {
+				middlewareHandlerTemplate.Execute(out, middlewareHandlerTempl{
+					ReceiverTypeName: k,
+					FuncName:         f.FuncName,
+					EndpointVar:      endpointVarName(f),
+					Middleware:       f.Middleware,
+				})
+				handlerName = "serve" + f.FuncName
+			}
 
-			if f.Method != "" {
-				method := http.MethodPost
-				if f.Method == http.MethodGet {
-					method = http.MethodGet
-				}
+			fmt.Fprintf(out, "func (h *%s) %s(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {\n", k, handlerName)
+			if len(f.Middleware) == 0 {
+				fmt.Fprintf(out, "	w, r, done := cfg.observe(w, r, %s)\n", endpointVarName(f))
+				fmt.Fprintln(out, "	defer done()")
+			}
+			fmt.Fprintln(out, "	defer cfg.recoverPanic(w, r)")
+			fmt.Fprintln(out)
+			if f.Stream == "" {
+				fmt.Fprintln(out, "	acceptable := negotiate(w, r)")
+			}
+			fmt.Fprintln(out, "	ctx := r.Context()")
 
+			if method := f.HttpMethod(); method != "" {
 				checkMethodTemplate.Execute(out, checkMethodTempl{
 					HttpMethod: method,
 				})
 			}
 
 			if f.Auth {
-				checkAuthTemplate.Execute(out, nil)
+				fmt.Fprintln(out)
+				fmt.Fprintln(out, "	_, authPhase := cfg.startPhase(ctx, \"auth\")")
+				fmt.Fprintln(out, "	defer authPhase.end(ErrRequestRejected)")
+				checkAuthTemplate.Execute(out, checkAuthTempl{
+					Schemes: f.AuthSchemes,
+				})
+			}
+
+			if len(f.Roles) > 0 {
+				checkRolesTemplate.Execute(out, checkRolesTempl{
+					Roles: f.Roles,
+				})
+			}
+
+			if f.Auth {
+				fmt.Fprintln(out, "	authPhase.end(nil)")
+			}
+
+			// 406 comes after the method and auth checks, their errors are
+			// already written in the negotiated format
+			if f.Stream == "" {
+				fmt.Fprintln(out)
+				fmt.Fprintln(out, "	if !acceptable {")
+				fmt.Fprintln(out, "		writeError(w, r, http.StatusNotAcceptable, \"not acceptable\")")
+				fmt.Fprintln(out, "		return")
+				fmt.Fprintln(out, "	}")
+			}
+
+			if f.RateLimit != nil {
+				checkRateLimitTemplate.Execute(out, checkRateLimitTempl{
+					EndpointVar: endpointVarName(f),
+				})
+			}
+
+			if f.MaxBody != "" || len(f.Consumes) > 0 {
+				checkBodyTemplate.Execute(out, checkBodyTempl{
+					MaxBody:  f.MaxBodyExpr(),
+					Consumes: f.Consumes,
+				})
 			}
 
 			fmt.Fprintln(out)
+			fmt.Fprintln(out, "	_, validatePhase := cfg.startPhase(ctx, \"validate\")")
+			fmt.Fprintln(out, "	defer validatePhase.end(ErrRequestRejected)")
 			fmt.Fprintf(out, "	params := %v{}\n", f.InTypeName)
 
 			var genStruct *GeneratedStruct
@@ -596,12 +921,41 @@ func generateCode(out io.Writer, funcs []GeneratedFunc, structs []GeneratedStruc
 			}
 
 			if genStruct != nil {
-				generateValidationCode(out, genStruct)
+				generateValidationCode(out, genStruct, getPathParams(f.Url))
 			}
 
-			responseTemplate.Execute(out, responseTempl{
-				FuncName: f.FuncName,
-			})
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, "	if info := requestInfo(r); info != nil {")
+			fmt.Fprintln(out, "		info.params = params")
+			fmt.Fprintln(out, "	}")
+			fmt.Fprintln(out, "	validatePhase.end(nil)")
+
+			if f.Idempotent {
+				checkIdempotencyTemplate.Execute(out, checkIdempotencyTempl{
+					EndpointVar: endpointVarName(f),
+				})
+			}
+
+			if f.Timeout != "" {
+				fmt.Fprintln(out)
+				fmt.Fprintf(out, "	ctx, cancel := context.WithTimeout(ctx, %s)\n", f.TimeoutExpr())
+				fmt.Fprintln(out, "	defer cancel()")
+			}
+
+			if f.Stream != "" {
+				streamTemplate.Execute(out, streamTempl{
+					FuncName: f.FuncName,
+					ItemType: f.StreamItemType,
+					Sink:     f.StreamSink,
+					SSE:      f.Stream == streamSSE,
+				})
+			} else {
+				responseTemplate.Execute(out, responseTempl{
+					FuncName: f.FuncName,
+					Envelope: f.Envelope,
+					Status:   f.StatusExpr(),
+				})
+			}
 
 			fmt.Fprint(out, "\n}\n\n")
 		}
@@ -611,24 +965,150 @@ func generateCode(out io.Writer, funcs []GeneratedFunc, structs []GeneratedStruc
 	return nil
 }
 
-func main() {
+func run(args []string) error {
+	flags := flag.NewFlagSet("handlers_gen", flag.ContinueOnError)
+	routerName := flags.String("router", "", "generate a router type with this name composing all annotated receivers")
+	roles := flags.String("roles", "", "comma separated roles from the lowest to the highest, used by min_role")
+	errorsFormat := flags.String("errors", errorsClassic, "error body format: classic {\"error\": ...} or problem for RFC 7807 application/problem+json")
+	envelope := flags.String("envelope", envelopeClassic, "success body: classic {\"response\": ..., \"error\": \"\"}, bare result or name of a generic envelope type, receivers may override it with // apigen:envelope")
+	routing := flags.String("routing", routingSwitch, "dispatching: switch or radix in ServeHTTP, mux for Register(*http.ServeMux)")
+	jsonEncoders := flags.Bool("jsonenc", true, "generate reflection-free AppendJSON for result types")
+	prometheus := flags.Bool("prometheus", false, "generate PrometheusMetrics recorder for ApiConfig.Metrics")
+	recordingTracer := flags.Bool("recording-tracer", false, "generate RecordingTracer keeping spans in memory for tests")
+	mounts := mountFlags{}
+	flags.Var(&mounts, "mount", "mount receiver into the router under prefix, e.g. MyApi=/api (repeatable)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: handlers_gen [flags] api.go api_handlers.go")
+	}
+
 	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, os.Args[1], nil, parser.ParseComments)
+	node, err := parser.ParseFile(fset, flags.Arg(0), nil, parser.ParseComments)
 	fmt.Println(node, err)
 
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	out, _ := os.Create(os.Args[2])
-
 	genFuncs := getGeneratedFuncs(node)
 
 	genStructs := getGeneratedStructs(node)
 
-	fmt.Fprintln(out, "// THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.")
-	fmt.Fprintln(out, `package `+node.Name.Name)
-	writeImports(out)
-	writeUtils(out)
-	generateCode(out, genFuncs, genStructs)
+	errorMappings, err := getErrorMappings(node)
+	if err != nil {
+		return err
+	}
+
+	middlewares, err := getMiddlewares(node)
+	if err != nil {
+		return err
+	}
+
+	if *errorsFormat != errorsClassic && *errorsFormat != errorsProblem {
+		return fmt.Errorf("unknown errors format %q", *errorsFormat)
+	}
+
+	err = checkRouting(*routing, genFuncs)
+	if err != nil {
+		return err
+	}
+
+	var ranking []string
+	if *roles != "" {
+		ranking = strings.Split(*roles, ",")
+	}
+
+	err = checkAuthSchemes(genFuncs)
+	if err != nil {
+		return err
+	}
+
+	err = checkStreams(genFuncs)
+	if err != nil {
+		return err
+	}
+
+	err = checkStatuses(genFuncs)
+	if err != nil {
+		return err
+	}
+
+	err = checkTimeouts(genFuncs)
+	if err != nil {
+		return err
+	}
+
+	err = checkMiddlewares(genFuncs, middlewares)
+	if err != nil {
+		return err
+	}
+
+	err = checkRateLimits(genFuncs)
+	if err != nil {
+		return err
+	}
+
+	err = checkBodies(genFuncs)
+	if err != nil {
+		return err
+	}
+
+	err = checkIdempotency(genFuncs)
+	if err != nil {
+		return err
+	}
+
+	err = resolveRoles(genFuncs, ranking)
+	if err != nil {
+		return err
+	}
+
+	envelopes, err := resolveEnvelopes(node, genFuncs, *envelope)
+	if err != nil {
+		return err
+	}
+
+	out := &bytes.Buffer{}
+
+	usage := getRuntimeUsage(genFuncs, *routing, *errorsFormat)
+	usage.Prometheus, usage.RecordingTracer = *prometheus, *recordingTracer
+	writeUtils(out, usage)
+	generateErrorMappings(out, errorMappings)
+	generateMiddlewares(out, middlewares)
+	generateLogValues(out, node, genStructs)
+	generateEnvelopeWrappers(out, envelopes)
+	if *jsonEncoders {
+		names, encoders := getJSONEncoderTypes(node, genFuncs)
+		generateJSONEncoders(out, names, encoders)
+	}
+	generateCode(out, genFuncs, genStructs, *routing)
+
+	if *routerName != "" {
+		receivers, funcsMap := groupFuncsByReceiver(genFuncs)
+
+		err = generateRouter(out, *routerName, mounts, receivers, funcsMap, *routing)
+		if err != nil {
+			return err
+		}
+	}
+
+	file := &bytes.Buffer{}
+	fmt.Fprintln(file, "// THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.")
+	fmt.Fprintln(file, `package `+node.Name.Name)
+	err = writeImports(file, out.Bytes())
+	if err != nil {
+		return err
+	}
+	file.Write(out.Bytes())
+
+	return os.WriteFile(flags.Arg(1), file.Bytes(), 0o644)
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,852 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testApiSource = `package main
+
+import "context"
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+type FirstApi struct{}
+
+type SecondApi struct{}
+
+type Params struct {
+	Login string ` + "`apivalidator:\"required\"`" + `
+}
+
+// apigen:api {"url": "/user/profile", "auth": false}
+func (srv *FirstApi) Profile(ctx context.Context, in Params) (*Params, error) {
+	return &in, nil
+}
+
+// apigen:api {"url": "/user/profile", "auth": true, "method": "POST"}
+func (srv *SecondApi) Profile(ctx context.Context, in Params) (*Params, error) {
+	return &in, nil
+}
+`
+
+func parseTestSource(t *testing.T, src string) *ast.File {
+	t.Helper()
+
+	node, err := parser.ParseFile(token.NewFileSet(), "api.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return node
+}
+
+func TestBuildRouterRoutes(t *testing.T) {
+	receivers, funcsMap := groupFuncsByReceiver(getGeneratedFuncs(parseTestSource(t, testApiSource)))
+
+	_, _, err := buildRouterRoutes(nil, receivers, funcsMap, routingSwitch)
+	if err == nil || !strings.Contains(err.Error(), "route conflict: /user/profile") {
+		t.Errorf("expected route conflict, got %v", err)
+	}
+
+	// ServeMux serves "/user/profile" and "POST /user/profile" side by side
+	if _, _, err := buildRouterRoutes(nil, receivers, funcsMap, routingMux); err != nil {
+		t.Errorf("different methods must not conflict in mux routing, got %v", err)
+	}
+	postOnly := getGeneratedFuncs(parseTestSource(t, strings.Replace(testApiSource, `"auth": false`, `"auth": false, "method": "POST"`, 1)))
+	receivers, postFuncs := groupFuncsByReceiver(postOnly)
+	if _, _, err := buildRouterRoutes(nil, receivers, postFuncs, routingMux); err == nil {
+		t.Error("expected route conflict of the same method in mux routing")
+	}
+
+	mounts := []routerMount{
+		{ReceiverTypeName: "SecondApi", Prefix: "/second"},
+		{ReceiverTypeName: "FirstApi", Prefix: "/first"},
+	}
+	_, routes, err := buildRouterRoutes(mounts, receivers, funcsMap, routingSwitch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || routes[0].Path != "/second/user/profile" || routes[1].Path != "/first/user/profile" {
+		t.Errorf("unexpected routes: %+v", routes)
+	}
+
+	_, _, err = buildRouterRoutes([]routerMount{{ReceiverTypeName: "ThirdApi"}}, receivers, funcsMap, routingSwitch)
+	if err == nil {
+		t.Error("expected error for receiver without apigen methods")
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	src := strings.Replace(testApiSource, `"/user/profile", "auth": false`, `"/user/{login}", "auth": false`, 1)
+	node := parseTestSource(t, src)
+	funcs := getGeneratedFuncs(node)
+
+	if err := checkRouting(routingSwitch, funcs); err == nil {
+		t.Error("expected switch routing to reject path params")
+	}
+	if err := checkRouting(routingRadix, funcs); err != nil {
+		t.Error(err)
+	}
+
+	for _, url := range []string{"/user/{login", "/user/login}", "/user/{}", "/user/{a/b}", "/user/file{login}.txt", "/user/{login}{id}"} {
+		badFuncs := getGeneratedFuncs(parseTestSource(t, strings.Replace(src, "/user/{login}", url, 1)))
+		if err := checkRouting(routingRadix, badFuncs); err == nil {
+			t.Errorf("expected %s to be rejected", url)
+		}
+	}
+
+	if key := routeKey("/user/{login}/posts/{id}"); key != "/user/{}/posts/{}" {
+		t.Errorf("unexpected route key %s", key)
+	}
+
+	out := &strings.Builder{}
+	generateCode(out, funcs, getGeneratedStructs(node), routingRadix)
+
+	if !strings.Contains(out.String(), `login := r.PathValue("login")`) {
+		t.Errorf("path param is not read from the path:\n%s", out)
+	}
+	if !strings.Contains(out.String(), `apiRadixRoute[FirstApi]{"/user/{login}", (*FirstApi).handlerProfile}`) {
+		t.Errorf("route is not added to the radix tree:\n%s", out)
+	}
+}
+
+func TestMuxRouting(t *testing.T) {
+	src := strings.Replace(testApiSource, `"/user/profile", "auth": false`, `"/user/{login}", "auth": false, "method": "GET"`, 1)
+	node := parseTestSource(t, src)
+	funcs := getGeneratedFuncs(node)
+
+	if err := checkRouting(routingMux, funcs); err != nil {
+		t.Fatal(err)
+	}
+
+	badFuncs := []GeneratedFunc{{ReceiverTypeName: "FirstApi", FuncName: "Profile", Url: "/user-{login}"}}
+	if err := checkRouting(routingMux, badFuncs); err == nil {
+		t.Error("expected partial segment param to be rejected")
+	}
+
+	out := &strings.Builder{}
+	generateCode(out, funcs, getGeneratedStructs(node), routingMux)
+
+	for _, expected := range []string{
+		`mux.HandleFunc("GET /user/{login}", cfg.bind(h.handlerProfile))`,
+		`mux.HandleFunc("POST /user/profile", cfg.bind(h.handlerProfile))`,
+		"func (h *FirstApiHandler) Register(mux *http.ServeMux) {\n\th.Api.register(mux, h.apiConfig())",
+		`login := r.PathValue("login")`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("%s not found in:\n%s", expected, out)
+		}
+	}
+}
+
+func TestResolveRoles(t *testing.T) {
+	ranking := []string{"user", "moderator", "admin"}
+	src := strings.Replace(testApiSource, `"auth": true, "method": "POST"`, `"auth": true, "method": "POST", "min_role": "moderator"`, 1)
+	node := parseTestSource(t, src)
+	funcs := getGeneratedFuncs(node)
+
+	if err := resolveRoles(funcs, ranking); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(funcs[1].Roles, ",") != "moderator,admin" {
+		t.Errorf("unexpected roles %v", funcs[1].Roles)
+	}
+
+	out := &strings.Builder{}
+	generateCode(out, funcs, getGeneratedStructs(node), routingSwitch)
+	if !strings.Contains(out.String(), `if !hasAnyRole(ctx, "moderator", "admin") {`) {
+		t.Errorf("roles are not checked:\n%s", out)
+	}
+
+	badCases := []string{
+		strings.Replace(testApiSource, `"auth": true, "method": "POST"`, `"auth": true, "min_role": "root"`, 1),
+		strings.Replace(testApiSource, `"auth": false`, `"auth": false, "roles": ["admin"]`, 1),
+	}
+	for _, src := range badCases {
+		if err := resolveRoles(getGeneratedFuncs(parseTestSource(t, src)), ranking); err == nil {
+			t.Errorf("expected error for %s", src)
+		}
+	}
+}
+
+func TestAuthSchemes(t *testing.T) {
+	src := strings.Replace(testApiSource, `"auth": true`, `"auth": "jwt"`, 1)
+	node := parseTestSource(t, src)
+	funcs := getGeneratedFuncs(node)
+
+	if err := checkAuthSchemes(funcs); err != nil {
+		t.Fatal(err)
+	}
+	if !funcs[1].Auth || strings.Join(funcs[1].AuthSchemes, ",") != "jwt" {
+		t.Errorf("unexpected auth %v %v", funcs[1].Auth, funcs[1].AuthSchemes)
+	}
+
+	out := &strings.Builder{}
+	generateCode(out, funcs, getGeneratedStructs(node), routingSwitch)
+	if !strings.Contains(out.String(), `ctx, err := cfg.authenticate(r, "jwt")`) {
+		t.Errorf("jwt scheme is not used:\n%s", out)
+	}
+
+	src = strings.Replace(testApiSource, `"auth": true`, `"auth": ["basic", "apikey"]`, 1)
+	funcs = getGeneratedFuncs(parseTestSource(t, src))
+	if err := checkAuthSchemes(funcs); err != nil || strings.Join(funcs[1].AuthSchemes, ",") != "basic,apikey" {
+		t.Errorf("unexpected schemes %v, error %v", funcs[1].AuthSchemes, err)
+	}
+
+	src = strings.Replace(testApiSource, `"auth": true`, `"auth": ["basic", "magic"]`, 1)
+	if err := checkAuthSchemes(getGeneratedFuncs(parseTestSource(t, src))); err == nil {
+		t.Error("expected unknown scheme to be rejected")
+	}
+}
+
+func TestErrorMappings(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+// apigen:error {"status": 404, "message": "user not exist"}
+var ErrNotFound = errors.New("not found")
+
+var (
+	// apigen:error {"status": 409}
+	ErrConflict = errors.New("conflict")
+	ErrOther    = errors.New("other")
+)
+
+// apigen:error {"status": 400}
+type ValidationError struct{}
+
+func (e *ValidationError) Error() string { return "invalid" }
+`
+	mappings, err := getErrorMappings(parseTestSource(t, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &strings.Builder{}
+	generateErrorMappings(out, mappings)
+
+	for _, expected := range []string{
+		`cfg.RegisterErrorMapping(ErrNotFound, 404, "user not exist")`,
+		`cfg.RegisterErrorMapping(ErrConflict, 409, "")`,
+		`RegisterErrorTypeMapping[*ValidationError](cfg, 400, "")`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("%s not found in:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out.String(), "ErrOther") {
+		t.Errorf("not annotated error is mapped:\n%s", out)
+	}
+
+	_, err = getErrorMappings(parseTestSource(t, strings.Replace(src, "func (e *ValidationError) Error", "func (e *ValidationError) String", 1)))
+	if err == nil {
+		t.Error("expected error for type without Error method")
+	}
+}
+
+func TestProblemErrors(t *testing.T) {
+	src := strings.Replace(testApiSource, "`apivalidator:\"required\"`", "`apivalidator:\"required,paramname=user_login\"`", 1)
+	node := parseTestSource(t, src)
+
+	out := &strings.Builder{}
+	generateCode(out, getGeneratedFuncs(node), getGeneratedStructs(node), routingSwitch)
+	if !strings.Contains(out.String(), `writeError(w, r, http.StatusBadRequest, "login must me not empty", "user_login")`) {
+		t.Errorf("invalid param is not reported:\n%s", out)
+	}
+
+	utils := &strings.Builder{}
+	writeUtils(utils, runtimeUsage{Problem: true})
+	if !strings.Contains(utils.String(), `header.Set("Content-Type", "application/problem+json")`) {
+		t.Errorf("problem details are not rendered:\n%s", utils)
+	}
+}
+
+func TestConditionalRuntime(t *testing.T) {
+	unused := []string{
+		"JWTAuthenticator", "BasicAuthenticator", "APIKeyAuthenticator", "CookieAuthenticator", "verifiedContext",
+		"apiStream", "ApiRateLimit", "RateLimitKeys", "checkBody", "IdempotencyStore", "apiRadixNode",
+		"PrometheusMetrics", "RecordingTracer", `"crypto/hmac"`, `"container/list"`, `"encoding/base64"`,
+	}
+
+	generated := generateTestFile(t, testApiSource)
+	typeCheck(t, testApiSource, generated)
+	for _, name := range unused {
+		if strings.Contains(generated, name) {
+			t.Errorf("%s is emitted without endpoints using it:\n%s", name, generated)
+		}
+	}
+
+	src := strings.Replace(testApiSource, `"auth": true`, `"auth": ["jwt", "cookie"], "ratelimit": {"rate": 1, "burst": 1}, "idempotent": true`, 1)
+	generated = generateTestFile(t, src, "-routing", "radix", "-prometheus", "-recording-tracer")
+	typeCheck(t, src, generated)
+	for _, name := range unused {
+		used := !slices.Contains([]string{"BasicAuthenticator", "APIKeyAuthenticator", "apiStream", "checkBody"}, name)
+		if strings.Contains(generated, name) != used {
+			t.Errorf("%s: expected emitted %v:\n%s", name, used, generated)
+		}
+	}
+}
+
+// typeCheck compiles the parsed source together with the generated code
+func typeCheck(t *testing.T, src, generated string) {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, 2)
+	for name, code := range map[string]string{"api.go": src, "api_handlers.go": generated} {
+		file, err := parser.ParseFile(fset, name, code, 0)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", name, err, code)
+		}
+		files = append(files, file)
+	}
+
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("main", fset, files, nil); err != nil {
+		t.Fatalf("generated code does not compile: %v\n%s", err, generated)
+	}
+}
+
+// goRun builds src, its generated handlers and program with the go tool and
+// returns the output of the program
+func goRun(t *testing.T, src, generated, program string) string {
+	t.Helper()
+
+	goBin, err := exec.LookPath("go")
+	if testing.Short() || err != nil {
+		t.Skip("running generated code needs the go tool")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":          "module apigencheck\n\ngo 1.22\n",
+		"api.go":          src,
+		"api_handlers.go": generated,
+		"main.go":         program,
+	}
+	for name, code := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(code), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goBin, "run", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+
+	return string(out)
+}
+
+// generateTestFile runs the whole generator on src
+func generateTestFile(t *testing.T, src string, args ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "api.go"), filepath.Join(dir, "api_handlers.go")
+	if err := os.WriteFile(in, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run(append(args, in, out)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestEnvelopes(t *testing.T) {
+	src := strings.Replace(testApiSource, "type SecondApi struct{}", `// apigen:envelope Envelope
+type SecondApi struct{}
+
+type Envelope[T any] struct {
+	Data T   `+"`json:\"data\"`"+`
+	Meta any `+"`json:\"meta,omitempty\"`"+`
+}`, 1)
+
+	generated := generateTestFile(t, src, "-envelope", "bare")
+	typeCheck(t, src, generated)
+
+	for _, expected := range []string{
+		"func wrapEnvelope[T any](data T) Envelope[T] {\n\treturn Envelope[T]{Data: data}\n}",
+		"writeResult(w, r, http.StatusOK, resp, wrapEnvelope(resp))",
+		"writeResult(w, r, http.StatusOK, resp, resp)\n",
+	} {
+		if !strings.Contains(generated, expected) {
+			t.Errorf("%s not found in:\n%s", expected, generated)
+		}
+	}
+
+	src = strings.Replace(src, "Data T ", "Data *T", 1)
+	if _, err := resolveEnvelopes(parseTestSource(t, src), getGeneratedFuncs(parseTestSource(t, src)), envelopeClassic); err == nil {
+		t.Error("expected envelope without data field to be rejected")
+	}
+}
+
+const testJSONSource = `
+
+type Inner struct {
+	Name string   ` + "`json:\"name,omitempty\"`" + `
+	Tags []string ` + "`json:\"tags\"`" + `
+}
+
+type Result struct {
+	ID      int64          ` + "`json:\"id\"`" + `
+	Score   float64        ` + "`json:\"score,omitempty\"`" + `
+	Ratio   float32
+	Active  bool           ` + "`json:\"active,omitempty\"`" + `
+	Skipped string         ` + "`json:\"-\"`" + `
+	Dash    string         ` + "`json:\"-,\"`" + `
+	hidden  int
+	Inner   Inner          ` + "`json:\"inner,omitempty\"`" + `
+	Parent  *Inner         ` + "`json:\"parent\"`" + `
+	Items   []*Inner       ` + "`json:\"items,omitempty\"`" + `
+	Grid    [2][]int       ` + "`json:\"grid\"`" + `
+	Count   *uint8         ` + "`json:\"count,omitempty\"`" + `
+	Extra   map[string]any ` + "`json:\"extra,omitempty\"`" + `
+	Raw     []byte         ` + "`json:\"raw\"`" + `
+	Any     any            ` + "`json:\"any\"`" + `
+}
+
+type Quoted struct {
+	N int ` + "`json:\"n,string\"`" + `
+}
+
+// apigen:api {"url": "/result"}
+func (srv *FirstApi) Result(ctx context.Context, in Params) (*Result, error) {
+	return &Result{hidden: 1}, nil
+}
+
+// apigen:api {"url": "/quoted"}
+func (srv *FirstApi) Quoted(ctx context.Context, in Params) (*Quoted, error) {
+	return &Quoted{}, nil
+}
+`
+
+// testJSONProgram prints results encoded with generated encoders when they
+// exist and with encoding/json otherwise
+const testJSONProgram = `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	count := uint8(3)
+	values := []any{
+		Result{},
+		&Result{
+			ID: -1, Score: 1e-7, Ratio: 0.25, Active: true, Skipped: "x", Dash: "<&>",
+			Inner: Inner{Name: "n", Tags: []string{}}, Parent: &Inner{Tags: []string{"a", " "}},
+			Items: []*Inner{nil, {Name: "i"}}, Grid: [2][]int{{1, 2}, nil}, Count: &count,
+			Extra: map[string]any{"k": 1}, Raw: []byte("raw"), Any: Inner{Name: "any"},
+		},
+		(*Result)(nil),
+		Quoted{N: 1},
+	}
+
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if appender, ok := value.(interface{ AppendJSON([]byte) ([]byte, error) }); ok && value != (*Result)(nil) {
+			data, err = appender.AppendJSON(nil)
+		}
+		fmt.Println(string(data), err)
+	}
+}
+`
+
+func TestJSONEncoders(t *testing.T) {
+	src := testApiSource + testJSONSource
+	generated := generateTestFile(t, src)
+	typeCheck(t, src, generated)
+
+	for _, expected := range []string{
+		"func (v Result) AppendJSON(dst []byte) ([]byte, error) {",
+		"func (v Inner) AppendJSON(dst []byte) ([]byte, error) {",
+		`dst = append(dst, ",\"-\":"...)`,
+		"dst, err = appendJSONValue(dst, v.Raw)",
+	} {
+		if !strings.Contains(generated, expected) {
+			t.Errorf("%s not found in:\n%s", expected, generated)
+		}
+	}
+	if strings.Contains(generated, "func (v Quoted) AppendJSON") || strings.Contains(generated, "v.hidden") || strings.Contains(generated, "MarshalJSON()") {
+		t.Errorf("unsupported struct or unexported field is encoded:\n%s", generated)
+	}
+
+	outputs := make([]string, 0, 2)
+	for _, args := range [][]string{nil, {"-jsonenc=false"}} {
+		outputs = append(outputs, goRun(t, src, generateTestFile(t, src, args...), testJSONProgram))
+	}
+
+	if outputs[0] != outputs[1] {
+		t.Errorf("generated encoders differ from encoding/json:\n%s\nencoding/json:\n%s", outputs[0], outputs[1])
+	}
+}
+
+func TestStreams(t *testing.T) {
+	src := testApiSource + `
+// apigen:api {"url": "/feed", "auth": true, "stream": "ndjson"}
+func (srv *FirstApi) Feed(ctx context.Context, in Params) (<-chan *Params, error) {
+	return nil, nil
+}
+
+// apigen:api {"url": "/events", "method": "GET", "stream": "sse"}
+func (srv *FirstApi) Events(ctx context.Context, in Params, send func(Params) error) error {
+	return send(in)
+}
+`
+	generated := generateTestFile(t, src)
+	typeCheck(t, src, generated)
+
+	for _, expected := range []string{
+		"stream := newApiStream(w, false)\n\tcallCtx, callPhase := cfg.startPhase(ctx, \"call\")\n\titems, err := h.Feed(callCtx, params)",
+		"stream := newApiStream(w, true)\n\tcallCtx, callPhase := cfg.startPhase(ctx, \"call\")\n\tif err := h.Events(callCtx, params, func(item Params) error {",
+		"func (v Params) AppendJSON(dst []byte) ([]byte, error) {",
+	} {
+		if !strings.Contains(generated, expected) {
+			t.Errorf("%s not found in:\n%s", expected, generated)
+		}
+	}
+
+	for _, item := range []struct{ Old, New string }{
+		{`"stream": "sse"`, `"stream": "websocket"`},
+		{`, "stream": "ndjson"`, ``},
+		{`(<-chan *Params, error)`, `(*Params, error)`},
+	} {
+		funcs := getGeneratedFuncs(parseTestSource(t, strings.Replace(src, item.Old, item.New, 1)))
+		if err := checkStreams(funcs); err == nil {
+			t.Errorf("expected error with %s", item.New)
+		}
+	}
+}
+
+// testStreamSource has endpoints streaming until the client goes away
+const testStreamSource = `
+var streamStopped = make(chan string, 2)
+
+// apigen:api {"url": "/feed", "stream": "ndjson"}
+func (srv *FirstApi) Feed(ctx context.Context, in Params) (<-chan *Params, error) {
+	items := make(chan *Params)
+	go func() {
+		defer close(items)
+		for {
+			select {
+			case items <- &in:
+			case <-ctx.Done():
+				streamStopped <- "feed"
+				return
+			}
+		}
+	}()
+	return items, nil
+}
+
+// apigen:api {"url": "/events", "method": "GET", "stream": "sse"}
+func (srv *FirstApi) Events(ctx context.Context, in Params, send func(Params) error) error {
+	for {
+		if err := send(in); err != nil {
+			streamStopped <- "events"
+			return err
+		}
+	}
+}
+`
+
+// testStreamProgram reads the first item of every stream and disconnects
+const testStreamProgram = `package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+func main() {
+	ts := httptest.NewServer(&FirstApi{})
+	defer ts.Close()
+
+	for _, path := range []string{"/feed", "/events"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+path+"?login=rvasily", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			panic(err)
+		}
+		line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		fmt.Print(resp.Header.Get("Content-Type"), " ", line)
+		cancel()
+		resp.Body.Close()
+
+		select {
+		case name := <-streamStopped:
+			fmt.Println(name, "stopped")
+		case <-time.After(10 * time.Second):
+			fmt.Println(path, "is still streaming")
+		}
+	}
+}
+`
+
+func TestStreamsClientGone(t *testing.T) {
+	src := testApiSource + testStreamSource
+	out := goRun(t, src, generateTestFile(t, src), testStreamProgram)
+
+	expected := "application/x-ndjson {\"Login\":\"rvasily\"}\nfeed stopped\n" +
+		"text/event-stream data: {\"Login\":\"rvasily\"}\nevents stopped\n"
+	if out != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out)
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	src := strings.Replace(testApiSource, `"auth": true, "method": "POST"`, `"auth": true, "method": "POST", "status": 201`, 1)
+	src = strings.Replace(src, `"/user/profile", "auth": false`, `"/user/profile", "auth": false, "status": 299`, 1)
+	generated := generateTestFile(t, src)
+	typeCheck(t, src, generated)
+
+	for _, expected := range []string{
+		"writeResult(w, r, http.StatusCreated, resp, response{Response: resp})",
+		"writeResult(w, r, 299, resp, response{Response: resp})",
+	} {
+		if !strings.Contains(generated, expected) {
+			t.Errorf("%s not found in:\n%s", expected, generated)
+		}
+	}
+
+	funcs := getGeneratedFuncs(parseTestSource(t, strings.Replace(src, `"status": 201`, `"status": 404`, 1)))
+	if err := checkStatuses(funcs); err == nil {
+		t.Error("expected error status to be rejected")
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	src := strings.Replace(testApiSource, `"auth": true, "method": "POST"`, `"auth": true, "method": "POST", "timeout": "1500ms"`, 1)
+	generated := generateTestFile(t, src)
+	typeCheck(t, src, generated)
+
+	expected := "ctx, cancel := context.WithTimeout(ctx, 1500 * time.Millisecond)\n\tdefer cancel()"
+	if !strings.Contains(generated, expected) {
+		t.Errorf("%s not found in:\n%s", expected, generated)
+	}
+	if strings.Contains(generated, "context.Background()") {
+		t.Error("handlers must derive from the request context")
+	}
+
+	for _, timeout := range []string{"2", "-1s"} {
+		funcs := getGeneratedFuncs(parseTestSource(t, strings.Replace(src, "1500ms", timeout, 1)))
+		if err := checkTimeouts(funcs); err == nil {
+			t.Errorf("expected timeout %s to be rejected", timeout)
+		}
+	}
+}
+
+func TestMiddlewares(t *testing.T) {
+	src := strings.Replace(testApiSource, `import "context"`, `import (
+	"context"
+	"net/http"
+)
+
+// apigen:middleware audit
+func audit(next http.Handler) http.Handler {
+	return next
+}
+
+// apigen:middleware ratelimit
+func limit(next http.Handler) http.Handler {
+	return next
+}`, 1)
+	src = strings.Replace(src, `"auth": true, "method": "POST"`, `"auth": true, "method": "POST", "middleware": ["audit", "ratelimit"]`, 1)
+	generated := generateTestFile(t, src)
+	typeCheck(t, src, generated)
+
+	for _, expected := range []string{
+		`cfg.Middlewares["ratelimit"] = limit`,
+		"}, \"audit\", \"ratelimit\").ServeHTTP(w, r)",
+		"func (h *SecondApi) serveProfile(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {",
+		"func (h *FirstApi) handlerProfile(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {",
+	} {
+		if !strings.Contains(generated, expected) {
+			t.Errorf("%s not found in:\n%s", expected, generated)
+		}
+	}
+
+	node := parseTestSource(t, strings.Replace(src, `"ratelimit"]`, `"cache"]`, 1))
+	middlewares, err := getMiddlewares(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkMiddlewares(getGeneratedFuncs(node), middlewares); err == nil || !strings.Contains(err.Error(), `"cache"`) {
+		t.Errorf("expected unknown middleware error, got %v", err)
+	}
+
+	if _, err := getMiddlewares(parseTestSource(t, strings.Replace(src, "apigen:middleware ratelimit", "apigen:middleware audit", 1))); err == nil {
+		t.Error("expected duplicate middleware error")
+	}
+}
+
+func TestLogValues(t *testing.T) {
+	src := strings.Replace(testApiSource, "`apivalidator:\"required\"`",
+		"`apivalidator:\"required,sensitive\"`\n\tAge int `apivalidator:\"paramname=user_age\"`", 1)
+	generated := generateTestFile(t, src)
+	typeCheck(t, src, generated)
+
+	expected := "func (p Params) LogValue() slog.Value {\n\treturn slog.GroupValue(\n\t\tslog.String(\"login\", \"[REDACTED]\"),\n\t\tslog.Int(\"user_age\", p.Age),\n\t)\n}"
+	if !strings.Contains(generated, expected) {
+		t.Errorf("%s not found in:\n%s", expected, generated)
+	}
+	if !strings.Contains(generated, "w, r, done := cfg.observe(w, r, apiEndpointFirstApiProfile)") {
+		t.Errorf("handler is not observed:\n%s", generated)
+	}
+}
+
+func TestTracingPhases(t *testing.T) {
+	src := strings.Replace(testApiSource, `"auth": true, "method": "POST"`, `"auth": true, "method": "POST", "roles": ["admin"], "timeout": "2s"`, 1)
+	generated := generateTestFile(t, src, "-roles", "user,admin")
+	typeCheck(t, src, generated)
+
+	for _, expected := range []string{
+		"Roles:      []string{\"admin\"},\n\tTimeout:    \"2s\",\n}",
+		"_, authPhase := cfg.startPhase(ctx, \"auth\")\n\tdefer authPhase.end(ErrRequestRejected)",
+		"_, validatePhase := cfg.startPhase(ctx, \"validate\")\n\tdefer validatePhase.end(ErrRequestRejected)",
+		"callCtx, callPhase := cfg.startPhase(ctx, \"call\")\n\tresp, err := h.Profile(callCtx, params)\n\tcallPhase.end(err)",
+		"_, encodePhase := cfg.startPhase(ctx, \"encode\")",
+	} {
+		if !strings.Contains(generated, expected) {
+			t.Errorf("%s not found in:\n%s", expected, generated)
+		}
+	}
+
+	handler := generated[strings.Index(generated, "func (h *SecondApi) handlerProfile("):]
+	phases := []string{"authPhase.end(nil)", "validatePhase.end(nil)", "context.WithTimeout", "callPhase.end(err)", "encodePhase.end(nil)"}
+	for i := 1; i < len(phases); i++ {
+		if strings.Index(handler, phases[i-1]) > strings.Index(handler, phases[i]) {
+			t.Errorf("%s must come before %s", phases[i-1], phases[i])
+		}
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	src := strings.Replace(testApiSource, `"auth": true, "method": "POST"`, `"auth": true, "method": "POST", "ratelimit": {"rate": 0.5, "key": "principal"}`, 1)
+	generated := generateTestFile(t, src)
+	typeCheck(t, src, generated)
+
+	for _, expected := range []string{
+		`RateLimit:  &ApiRateLimit{Rate: 0.5, Burst: 1, Key: "principal"},`,
+		"authPhase.end(nil)\n\n\tif !acceptable {\n\t\twriteError(w, r, http.StatusNotAcceptable, \"not acceptable\")\n\t\treturn\n\t}\n\n\t// check rate limit\n\tif !cfg.allowRequest(ctx, w, r, apiEndpointSecondApiProfile) {",
+	} {
+		if !strings.Contains(generated, expected) {
+			t.Errorf("%s not found in:\n%s", expected, generated)
+		}
+	}
+
+	funcs := getGeneratedFuncs(parseTestSource(t, strings.Replace(src, `, "key": "principal"`, ``, 1)))
+	if err := checkRateLimits(funcs); err != nil || funcs[1].RateLimit.Key != "ip" {
+		t.Errorf("expected ip key by default, got %v %+v", err, funcs[1].RateLimit)
+	}
+
+	for _, item := range []struct{ Old, New string }{
+		{`"rate": 0.5`, `"rate": 0`},
+		{`"rate": 0.5`, `"rate": 1, "burst": -1`},
+		{`"principal"`, `"header:"`},
+		{`"principal"`, `"cookie"`},
+		{`"auth": true, "method": "POST"`, `"auth": false, "method": "POST"`},
+	} {
+		funcs := getGeneratedFuncs(parseTestSource(t, strings.Replace(src, item.Old, item.New, 1)))
+		if err := checkRateLimits(funcs); err == nil {
+			t.Errorf("expected error with %s", item.New)
+		}
+	}
+}
+
+func TestBodyLimits(t *testing.T) {
+	for size, expected := range map[string]int64{
+		"100":   100,
+		"512KB": 512 << 10,
+		"1MB":   1 << 20,
+		"1 mib": 1 << 20,
+		"2GB":   2 << 30,
+		"1500B": 1500,
+		"0":     0,
+		"-1KB":  0,
+		"1.5MB": 0,
+		"1TB":   0,
+		"":      0,
+	} {
+		parsed, err := parseBodySize(size)
+		if parsed != expected || (err == nil) != (expected > 0) {
+			t.Errorf("%q: expected %d, got %d %v", size, expected, parsed, err)
+		}
+	}
+
+	src := strings.Replace(testApiSource, `"auth": true, "method": "POST"`, `"auth": true, "method": "POST", "max_body": "64KB", "consumes": ["Multipart/Form-Data", "application/x-www-form-urlencoded"]`, 1)
+	generated := generateTestFile(t, src)
+	typeCheck(t, src, generated)
+
+	expected := "// check body\n\tif !checkBody(w, r, 64 << 10, \"multipart/form-data\", \"application/x-www-form-urlencoded\") {"
+	if !strings.Contains(generated, expected) {
+		t.Errorf("%s not found in:\n%s", expected, generated)
+	}
+
+	for _, item := range []struct{ Old, New string }{
+		{`"64KB"`, `"lots"`},
+		{`"Multipart/Form-Data"`, `"multipart/*"`},
+		{`"Multipart/Form-Data"`, `"multipart/form-data; boundary=x"`},
+		{`"Multipart/Form-Data"`, `"application/json"`},
+	} {
+		funcs := getGeneratedFuncs(parseTestSource(t, strings.Replace(src, item.Old, item.New, 1)))
+		if err := checkBodies(funcs); err == nil {
+			t.Errorf("expected error with %s", item.New)
+		}
+	}
+}
+
+func TestIdempotentEndpoints(t *testing.T) {
+	src := strings.Replace(testApiSource, `"auth": true, "method": "POST"`, `"auth": true, "method": "POST", "idempotent": true`, 1)
+	generated := generateTestFile(t, src)
+	typeCheck(t, src, generated)
+
+	expected := "validatePhase.end(nil)\n\n\t// check idempotency key\n\tw, release, ok := cfg.idempotent(ctx, w, r, apiEndpointSecondApiProfile)"
+	if !strings.Contains(generated, expected) {
+		t.Errorf("%s not found in:\n%s", expected, generated)
+	}
+
+	src += `
+// apigen:api {"url": "/feed", "stream": "ndjson", "idempotent": true}
+func (srv *FirstApi) Feed(ctx context.Context, in Params) (<-chan Params, error) {
+	return nil, nil
+}
+`
+	if err := checkIdempotency(getGeneratedFuncs(parseTestSource(t, src))); err == nil {
+		t.Error("expected error with idempotent stream")
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import "text/template"
+
+// configTemplate is emitted into every generated file, ApiConfig carries
+// dependencies shared by all generated handlers, fields of unused features
+// are left out
+var configTemplate = template.Must(template.New("configTempl").Parse(`
+// ApiConfig holds runtime dependencies of the generated handlers
+type ApiConfig struct {
+	// Authenticator checks requests to endpoints with "auth": true
+	Authenticator Authenticator
+	// Authenticators check requests to endpoints with "auth": "<scheme>" or
+	// a list of schemes, e.g. "jwt", "basic", "apikey", "cookie"
+	Authenticators map[string]Authenticator
+	// ErrorLogger gets errors which are answered with 500 "internal error"
+	ErrorLogger func(r *http.Request, err error)
+	// PanicHook gets panics recovered in handlers with their stack
+	PanicHook func(r *http.Request, value any, stack []byte)
+	// Middlewares wrap endpoints with "middleware": [...] by name, functions
+	// marked with apigen:middleware are registered by NewApiConfig
+	Middlewares map[string]func(http.Handler) http.Handler
+	// Metrics records every request when set{{if .Prometheus}}, e.g. NewPrometheusMetrics(){{end}}
+	Metrics MetricsRecorder
+	// AccessLogger writes a record per request when set, params fields with
+	// the apivalidator "sensitive" option are redacted
+	AccessLogger *slog.Logger
+	// Tracer gets a span per request with "auth", "validate", "call" and
+	// "encode" children, NoopTracer by default
+	Tracer Tracer
+{{- if .RateLimit}}
+	// RateLimitKeys bounds callers remembered by every "ratelimit" endpoint,
+	// DefaultRateLimitKeys when 0
+	RateLimitKeys int
+{{- end}}
+{{- if .Idempotency}}
+	// Idempotency keeps responses of "idempotent" endpoints by Idempotency-Key,
+	// NewApiConfig keeps DefaultIdempotencyKeys of them in memory for
+	// DefaultIdempotencyTTL, keys are not checked when nil
+	Idempotency IdempotencyStore
+{{- end}}
+
+	errorMappings []apiErrorMapping
+{{- if .RateLimit}}
+	rateLimiters  sync.Map
+{{- end}}
+}
+
+type apiErrorMapping struct {
+	// match returns the error from the chain the mapping is declared for
+	match   func(err error) (error, bool)
+	status  int
+	message string
+}
+
+func NewApiConfig() *ApiConfig {
+	cfg := &ApiConfig{
+		Authenticator: TokenAuthenticator{
+			Header: "X-Auth",
+			Tokens: map[string]Principal{
+				"100500": {ID: "100500"},
+			},
+		},
+		Authenticators: map[string]Authenticator{},
+		ErrorLogger: func(r *http.Request, err error) {
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		},
+		PanicHook: func(r *http.Request, value any, stack []byte) {
+			log.Printf("%s %s: panic: %v\n%s", r.Method, r.URL.Path, value, stack)
+		},
+		Middlewares: map[string]func(http.Handler) http.Handler{},
+		Tracer:      NoopTracer{},
+{{- if .Idempotency}}
+		Idempotency: NewMemoryIdempotencyStore(DefaultIdempotencyTTL),
+{{- end}}
+	}
+	registerErrorMappings(cfg)
+	registerMiddlewares(cfg)
+
+	return cfg
+}
+
+// DefaultApiConfig is used by receivers served directly, their handlers and
+// routers carry their own Config
+var DefaultApiConfig = NewApiConfig()
+
+// authenticate tries Authenticators of schemes in order, the default one without
+// schemes, missing authenticators reject the request
+func (cfg *ApiConfig) authenticate(r *http.Request, schemes ...string) (context.Context, error) {
+	if len(schemes) == 0 {
+		if cfg.Authenticator == nil {
+			return nil, errUnauthorized
+		}
+		return cfg.Authenticator.Authenticate(r)
+	}
+
+	err := errUnauthorized
+	for _, scheme := range schemes {
+		authenticator, ok := cfg.Authenticators[scheme]
+		if scheme == "token" {
+			authenticator, ok = cfg.Authenticator, cfg.Authenticator != nil
+		}
+		if !ok || authenticator == nil {
+			continue
+		}
+
+		var ctx context.Context
+		ctx, err = authenticator.Authenticate(r)
+		if err == nil {
+			return ctx, nil
+		}
+	}
+
+	return nil, err
+}
+
+// errorResponse turns an error of the api method into status and public message,
+// anything but ApiError, mapped errors and expired or canceled contexts is
+// hidden from the client
+func (cfg *ApiConfig) errorResponse(r *http.Request, err error) (int, string) {
+	var apiError ApiError
+	if errors.As(err, &apiError) {
+		return apiError.HTTPStatus, apiError.Error()
+	}
+
+	var apiErrorPtr *ApiError
+	if errors.As(err, &apiErrorPtr) && apiErrorPtr != nil {
+		return apiErrorPtr.HTTPStatus, apiErrorPtr.Error()
+	}
+
+	for _, mapping := range cfg.errorMappings {
+		matched, ok := mapping.match(err)
+		if !ok {
+			continue
+		}
+		if mapping.message == "" {
+			return mapping.status, matched.Error()
+		}
+
+		return mapping.status, mapping.message
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable, "request canceled"
+	}
+
+	if cfg.ErrorLogger != nil {
+		cfg.ErrorLogger(r, err)
+	}
+
+	return http.StatusInternalServerError, "internal error"
+}
+
+// recoverPanic answers 500 when the handler or the api method panics,
+// it must be deferred directly
+func (cfg *ApiConfig) recoverPanic(w http.ResponseWriter, r *http.Request) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	if value == http.ErrAbortHandler {
+		panic(value)
+	}
+
+	if cfg.PanicHook != nil {
+		cfg.PanicHook(r, value, debug.Stack())
+	}
+
+	writeError(w, r, http.StatusInternalServerError, "internal error")
+}
+
+// RegisterErrorMapping answers errors matching target with errors.Is,
+// an empty message exposes the text of the matched error
+func (cfg *ApiConfig) RegisterErrorMapping(target error, status int, message string) {
+	cfg.errorMappings = append(cfg.errorMappings, apiErrorMapping{
+		match: func(err error) (error, bool) {
+			return target, errors.Is(err, target)
+		},
+		status:  status,
+		message: message,
+	})
+}
+
+// wrap applies named Middlewares to handler, the first name is the outermost
+func (cfg *ApiConfig) wrap(handler http.HandlerFunc, names ...string) http.Handler {
+	var next http.Handler = handler
+	for i := len(names) - 1; i >= 0; i-- {
+		middleware, ok := cfg.Middlewares[names[i]]
+		if !ok {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if cfg.ErrorLogger != nil {
+					cfg.ErrorLogger(r, fmt.Errorf("middleware %q is not registered", names[i]))
+				}
+				writeError(w, r, http.StatusInternalServerError, "internal error")
+			})
+		}
+		next = middleware(next)
+	}
+
+	return next
+}
+
+// RegisterErrorTypeMapping answers errors of type T found with errors.As
+func RegisterErrorTypeMapping[T error](cfg *ApiConfig, status int, message string) {
+	cfg.errorMappings = append(cfg.errorMappings, apiErrorMapping{
+		match: func(err error) (error, bool) {
+			var target T
+			ok := errors.As(err, &target)
+			return target, ok
+		},
+		status:  status,
+		message: message,
+	})
+}
+
+// bind adapts a generated handler to http.HandlerFunc
+func (cfg *ApiConfig) bind(handler func(http.ResponseWriter, *http.Request, *ApiConfig)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, cfg)
+	}
+}
+`))
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io"
+	"strings"
+	"text/template"
+)
+
+const (
+	envelopeClassic = "classic"
+	envelopeBare    = "bare"
+)
+
+var apiGenEnvelopePrefix = "// apigen:envelope "
+
+// envelopeType is a user generic type like
+//
+//	type Envelope[T any] struct {
+//		Data T `json:"data"`
+//		Meta any `json:"meta,omitempty"`
+//	}
+//
+// the generated wrapper puts the result into its only field of type T
+type envelopeType struct {
+	Name       string
+	TypeParam  string
+	Constraint string
+	DataField  string
+}
+
+// getReceiverEnvelopes reads "// apigen:envelope <envelope>" of receiver types
+func getReceiverEnvelopes(node *ast.File) map[string]string {
+	envelopes := make(map[string]string)
+
+	for _, decl := range node.Decls {
+		g, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range g.Specs {
+			currType, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			for _, doc := range []*ast.CommentGroup{currType.Doc, groupDoc(g)} {
+				if doc == nil {
+					continue
+				}
+				for _, comment := range doc.List {
+					if envelope, found := strings.CutPrefix(comment.Text, apiGenEnvelopePrefix); found {
+						envelopes[currType.Name.Name] = strings.TrimSpace(envelope)
+					}
+				}
+			}
+		}
+	}
+
+	return envelopes
+}
+
+func findEnvelopeType(node *ast.File, name string) (envelopeType, error) {
+	for _, decl := range node.Decls {
+		g, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range g.Specs {
+			currType, ok := spec.(*ast.TypeSpec)
+			if !ok || currType.Name.Name != name {
+				continue
+			}
+
+			currStruct, ok := currType.Type.(*ast.StructType)
+			if !ok || currType.TypeParams == nil || currType.TypeParams.NumFields() != 1 {
+				return envelopeType{}, fmt.Errorf("envelope %s must be a struct with one type param", name)
+			}
+
+			envelope := envelopeType{
+				Name:       name,
+				TypeParam:  currType.TypeParams.List[0].Names[0].Name,
+				Constraint: types.ExprString(currType.TypeParams.List[0].Type),
+			}
+
+			for _, field := range currStruct.Fields.List {
+				ident, ok := field.Type.(*ast.Ident)
+				if ok && ident.Name == envelope.TypeParam && len(field.Names) == 1 {
+					envelope.DataField = field.Names[0].Name
+				}
+			}
+
+			if envelope.DataField == "" {
+				return envelopeType{}, fmt.Errorf("envelope %s has no field of type %s", name, envelope.TypeParam)
+			}
+
+			return envelope, nil
+		}
+	}
+
+	return envelopeType{}, fmt.Errorf("envelope type %s not found", name)
+}
+
+// resolveEnvelopes sets the envelope of every func, receiver annotations
+// override defaultEnvelope. It returns user envelope types to wrap results in
+func resolveEnvelopes(node *ast.File, funcs []GeneratedFunc, defaultEnvelope string) ([]envelopeType, error) {
+	receiverEnvelopes := getReceiverEnvelopes(node)
+	envelopes := make([]envelopeType, 0)
+	seen := make(map[string]bool)
+
+	for i, f := range funcs {
+		envelope, ok := receiverEnvelopes[f.ReceiverTypeName]
+		if !ok {
+			envelope = defaultEnvelope
+		}
+		funcs[i].Envelope = envelope
+
+		if envelope == envelopeClassic || envelope == envelopeBare || seen[envelope] {
+			continue
+		}
+
+		envelopeType, err := findEnvelopeType(node, envelope)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", f.ReceiverTypeName, f.FuncName, err)
+		}
+
+		seen[envelope] = true
+		envelopes = append(envelopes, envelopeType)
+	}
+
+	return envelopes, nil
+}
+
+var envelopeWrapTemplate = template.Must(template.New("envelopeWrapTempl").Parse(`
+{{- range .}}
+func wrap{{.Name}}[{{.TypeParam}} {{.Constraint}}](data {{.TypeParam}}) {{.Name}}[{{.TypeParam}}] {
+	return {{.Name}}[{{.TypeParam}}]{ {{- .DataField}}: data}
+}
+{{end}}`))
+
+func generateEnvelopeWrappers(out io.Writer, envelopes []envelopeType) error {
+	return envelopeWrapTemplate.Execute(out, envelopes)
+}
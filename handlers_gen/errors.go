@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"strings"
+	"text/template"
+)
+
+var apiGenErrorPrefix = "// apigen:error "
+
+// ApiGenError is the annotation of sentinel error vars and error types
+type ApiGenError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+type errorMapping struct {
+	ApiGenError
+	// Target is a sentinel var for errors.Is or a type for errors.As
+	Target string
+	IsType bool
+}
+
+func getApiGenError(docs ...*ast.CommentGroup) (*ApiGenError, error) {
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+
+		for _, comment := range doc.List {
+			str, found := strings.CutPrefix(comment.Text, apiGenErrorPrefix)
+			if !found {
+				continue
+			}
+
+			apiGen := &ApiGenError{}
+			if err := json.Unmarshal([]byte(str), apiGen); err != nil {
+				return nil, err
+			}
+			if apiGen.Status == 0 {
+				return nil, fmt.Errorf("apigen:error needs status: %s", str)
+			}
+
+			return apiGen, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// errorReceivers maps type names to the receiver of their Error method,
+// "*T" when it is declared on the pointer
+func errorReceivers(node *ast.File) map[string]string {
+	receivers := make(map[string]string)
+
+	for _, decl := range node.Decls {
+		f, ok := decl.(*ast.FuncDecl)
+		if !ok || f.Recv == nil || f.Name.Name != "Error" {
+			continue
+		}
+
+		switch t := f.Recv.List[0].Type.(type) {
+		case *ast.Ident:
+			receivers[t.Name] = t.Name
+		case *ast.StarExpr:
+			if ident, ok := t.X.(*ast.Ident); ok {
+				receivers[ident.Name] = "*" + ident.Name
+			}
+		}
+	}
+
+	return receivers
+}
+
+func getErrorMappings(node *ast.File) ([]errorMapping, error) {
+	mappings := make([]errorMapping, 0)
+	receivers := errorReceivers(node)
+
+	for _, decl := range node.Decls {
+		g, ok := decl.(*ast.GenDecl)
+		if !ok || (g.Tok != token.VAR && g.Tok != token.TYPE) {
+			continue
+		}
+
+		for _, spec := range g.Specs {
+			switch spec := spec.(type) {
+			case *ast.ValueSpec:
+				apiGen, err := getApiGenError(spec.Doc, groupDoc(g))
+				if err != nil {
+					return nil, err
+				}
+				if apiGen == nil {
+					continue
+				}
+
+				for _, name := range spec.Names {
+					mappings = append(mappings, errorMapping{ApiGenError: *apiGen, Target: name.Name})
+				}
+			case *ast.TypeSpec:
+				apiGen, err := getApiGenError(spec.Doc, groupDoc(g))
+				if err != nil {
+					return nil, err
+				}
+				if apiGen == nil {
+					continue
+				}
+
+				target, ok := receivers[spec.Name.Name]
+				if !ok {
+					return nil, fmt.Errorf("apigen:error type %s has no Error method", spec.Name.Name)
+				}
+
+				mappings = append(mappings, errorMapping{ApiGenError: *apiGen, Target: target, IsType: true})
+			}
+		}
+	}
+
+	return mappings, nil
+}
+
+// groupDoc returns the doc of a single spec declaration like "var ErrX = ...",
+// docs of grouped declarations belong to the whole group
+func groupDoc(g *ast.GenDecl) *ast.CommentGroup {
+	if g.Lparen.IsValid() {
+		return nil
+	}
+
+	return g.Doc
+}
+
+var errorMappingsTemplate = template.Must(template.New("errorMappingsTempl").Parse(`
+// registerErrorMappings adds mappings declared with apigen:error
+func registerErrorMappings(cfg *ApiConfig) {
+	{{- range .}}
+	{{if .IsType}}RegisterErrorTypeMapping[{{.Target}}](cfg, {{.Status}}, {{printf "%q" .Message}}){{else}}cfg.RegisterErrorMapping({{.Target}}, {{.Status}}, {{printf "%q" .Message}}){{end}}
+	{{- end}}
+}
+`))
+
+func generateErrorMappings(out io.Writer, mappings []errorMapping) error {
+	return errorMappingsTemplate.Execute(out, mappings)
+}
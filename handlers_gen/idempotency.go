@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// checkIdempotency rejects "idempotent" on streams, their responses can't be
+// replayed
+func checkIdempotency(funcs []GeneratedFunc) error {
+	for _, f := range funcs {
+		if f.Idempotent && f.Stream != "" {
+			return fmt.Errorf("%s.%s: idempotent can't be used with stream", f.ReceiverTypeName, f.FuncName)
+		}
+	}
+
+	return nil
+}
+
+type checkIdempotencyTempl struct {
+	EndpointVar string
+}
+
+var checkIdempotencyTemplate = template.Must(template.New("checkIdempotencyTempl").Parse(`
+	// check idempotency key
+	w, release, ok := cfg.idempotent(ctx, w, r, {{.EndpointVar}})
+	if !ok {
+		return
+	}
+	defer release()
+`))
+
+// idempotencyRuntime replays responses of endpoints with "idempotent": true
+// to retries carrying the same Idempotency-Key
+var idempotencyRuntime = `
+// IdempotentResponse is the first response to an Idempotency-Key,
+// Fingerprint identifies the params of the request it answered
+type IdempotentResponse struct {
+	Status      int
+	Header      http.Header
+	Body        []byte
+	Fingerprint string
+}
+
+// ErrIdempotencyInFlight is returned by Begin while the first request with
+// the key is served, the duplicate is answered with 409
+var ErrIdempotencyInFlight = errors.New("request with the idempotency key is in flight")
+
+// IdempotencyStore keeps responses by key, keys are scoped by the endpoint
+// and the principal
+type IdempotencyStore interface {
+	// Begin reserves key, for a finished request it returns its response
+	Begin(ctx context.Context, key string) (*IdempotentResponse, error)
+	// Save stores the response of the reserved key
+	Save(ctx context.Context, key string, resp *IdempotentResponse) error
+	// Release drops the reservation, the next request with key is served again
+	Release(ctx context.Context, key string)
+}
+
+const (
+	// DefaultIdempotencyTTL is how long NewApiConfig replays responses
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// DefaultIdempotencyKeys bounds the keys of MemoryIdempotencyStore
+	DefaultIdempotencyKeys = 10000
+)
+
+// idempotencySweeps is how many times per TTL expired responses are dropped
+const idempotencySweeps = 10
+
+type idempotencyEntry struct {
+	key     string
+	resp    *IdempotentResponse
+	expires time.Time
+}
+
+// MemoryIdempotencyStore keeps responses in memory for TTL after they are
+// saved, in flight reservations don't expire. When MaxKeys is reached the
+// least recently used key is forgotten, DefaultIdempotencyKeys when 0
+type MemoryIdempotencyStore struct {
+	TTL     time.Duration
+	MaxKeys int
+
+	mu        sync.Mutex
+	entries   map[string]*list.Element
+	lru       *list.List
+	lastSweep time.Time
+}
+
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{TTL: ttl}
+}
+
+func (s *MemoryIdempotencyStore) Begin(ctx context.Context, key string) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.entries == nil {
+		s.entries = make(map[string]*list.Element)
+		s.lru = list.New()
+	}
+	if now.Sub(s.lastSweep) > s.TTL/idempotencySweeps {
+		for elem := s.lru.Front(); elem != nil; {
+			next := elem.Next()
+			if entry := elem.Value.(*idempotencyEntry); entry.resp != nil && now.After(entry.expires) {
+				s.remove(elem)
+			}
+			elem = next
+		}
+		s.lastSweep = now
+	}
+
+	elem, ok := s.entries[key]
+	if !ok {
+		s.store(&idempotencyEntry{key: key})
+		return nil, nil
+	}
+
+	entry := elem.Value.(*idempotencyEntry)
+	s.lru.MoveToFront(elem)
+	switch {
+	case entry.resp != nil && now.After(entry.expires):
+		elem.Value = &idempotencyEntry{key: key}
+		return nil, nil
+	case entry.resp == nil:
+		return nil, ErrIdempotencyInFlight
+	default:
+		return entry.resp, nil
+	}
+}
+
+func (s *MemoryIdempotencyStore) Save(ctx context.Context, key string, resp *IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &idempotencyEntry{key: key, resp: resp, expires: time.Now().Add(s.TTL)}
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.lru.MoveToFront(elem)
+		return nil
+	}
+	if s.entries == nil {
+		s.entries = make(map[string]*list.Element)
+		s.lru = list.New()
+	}
+	s.store(entry)
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+}
+
+// store adds entry as the most recently used, evicting the oldest when full
+func (s *MemoryIdempotencyStore) store(entry *idempotencyEntry) {
+	maxKeys := s.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = DefaultIdempotencyKeys
+	}
+	for s.lru.Len() >= maxKeys {
+		s.remove(s.lru.Back())
+	}
+	s.entries[entry.key] = s.lru.PushFront(entry)
+}
+
+func (s *MemoryIdempotencyStore) remove(elem *list.Element) {
+	s.lru.Remove(elem)
+	delete(s.entries, elem.Value.(*idempotencyEntry).key)
+}
+
+// apiRecordingWriter copies the response for IdempotencyStore.Save
+type apiRecordingWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (w *apiRecordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *apiRecordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body = append(w.body, b...)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *apiRecordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func apiReleaseNothing() {}
+
+// idempotentFingerprint hashes the parsed params, Encode sorts them by name
+func idempotentFingerprint(r *http.Request) string {
+	sum := sha256.Sum256([]byte(r.Form.Encode()))
+	return hex.EncodeToString(sum[:])
+}
+
+// idempotent replays the saved response of the Idempotency-Key, a key reused
+// with other params is answered with 422. Otherwise it records the response,
+// the returned func saves it and must be deferred, server errors and panics
+// release the key so the client may retry
+func (cfg *ApiConfig) idempotent(ctx context.Context, w http.ResponseWriter, r *http.Request, endpoint *ApiEndpoint) (http.ResponseWriter, func(), bool) {
+	header := r.Header.Get("Idempotency-Key")
+	if cfg.Idempotency == nil || header == "" {
+		return w, apiReleaseNothing, true
+	}
+	if len(header) > 255 {
+		writeError(w, r, http.StatusBadRequest, "idempotency key too long")
+		return w, apiReleaseNothing, false
+	}
+
+	principal, _ := PrincipalFromContext(ctx)
+	key := endpoint.Receiver + "." + endpoint.Method + "\x00" + principal.ID + "\x00" + header
+
+	fingerprint := idempotentFingerprint(r)
+	saved, err := cfg.Idempotency.Begin(ctx, key)
+	switch {
+	case errors.Is(err, ErrIdempotencyInFlight):
+		writeError(w, r, http.StatusConflict, "request with the idempotency key is in progress")
+		return w, apiReleaseNothing, false
+	case err != nil:
+		status, message := cfg.errorResponse(r, err)
+		writeError(w, r, status, message)
+		return w, apiReleaseNothing, false
+	case saved != nil && saved.Fingerprint != fingerprint:
+		writeError(w, r, http.StatusUnprocessableEntity, "idempotency key is used with other params")
+		return w, apiReleaseNothing, false
+	case saved != nil:
+		for name, values := range saved.Header {
+			if name != "X-Request-Id" {
+				w.Header()[name] = values
+			}
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(saved.Status)
+		w.Write(saved.Body)
+		return w, apiReleaseNothing, false
+	}
+
+	rw := &apiRecordingWriter{ResponseWriter: w}
+	return rw, func() {
+		ctx := context.WithoutCancel(ctx)
+		if rw.status == 0 || rw.status >= http.StatusInternalServerError {
+			cfg.Idempotency.Release(ctx, key)
+			return
+		}
+
+		err := cfg.Idempotency.Save(ctx, key, &IdempotentResponse{
+			Status:      rw.status,
+			Header:      rw.header,
+			Body:        rw.body,
+			Fingerprint: fingerprint,
+		})
+		if err != nil && cfg.ErrorLogger != nil {
+			cfg.ErrorLogger(r, err)
+		}
+	}, true
+}
+`
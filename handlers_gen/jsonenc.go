@@ -0,0 +1,551 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonRuntime is used by the generated AppendJSON methods and by writeResponse,
+// strings are escaped the same way as encoding/json does it
+var jsonRuntime = `
+// jsonAppender is implemented by result types with generated encoders
+type jsonAppender interface {
+	AppendJSON(dst []byte) ([]byte, error)
+}
+
+const jsonHex = "0123456789abcdef"
+
+// appendJSONString appends s as a JSON string with HTML characters escaped
+// and invalid UTF-8 replaced, as encoding/json does
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', jsonHex[b>>4], jsonHex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i += size
+			start = i
+			continue
+		}
+
+		if c == '\u2028' || c == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', jsonHex[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+
+		i += size
+	}
+
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// appendJSONFloat formats f like encoding/json: exponent only for very
+// small or large values and an error for NaN and infinities
+func appendJSONFloat(dst []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return dst, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	dst = strconv.AppendFloat(dst, f, format, -1, bits)
+	if format == 'e' {
+		// e-09 to e-9
+		if n := len(dst); n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+
+	return dst, nil
+}
+
+// appendJSONValue uses the generated encoder when v has one, slices, arrays
+// and pointers of such types are walked here, the rest goes to the pooled
+// encoding/json encoder
+func appendJSONValue(dst []byte, v any) ([]byte, error) {
+	if appender, ok := v.(jsonAppender); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return append(dst, "null"...), nil
+		}
+		return appender.AppendJSON(dst)
+	}
+
+	if rv := reflect.ValueOf(v); rv.IsValid() && jsonAppendable(rv.Type()) {
+		return appendJSONReflect(dst, rv)
+	}
+
+	buf := responseBufferPool.Get().(*responseBuffer)
+	defer putResponseBuffer(buf)
+	if err := buf.encoder.Encode(v); err != nil {
+		return dst, err
+	}
+
+	// Encode ends the value with a newline
+	return append(dst, buf.Bytes()[:buf.Len()-1]...), nil
+}
+
+var (
+	jsonAppenderType  = reflect.TypeFor[jsonAppender]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// jsonAppendable reports whether t has a generated encoder or is a slice,
+// array or pointer leading to one, types encoding themselves are left to
+// encoding/json. Recursive types like type L []L give up after a few levels
+func jsonAppendable(t reflect.Type) bool {
+	for depth := 0; depth < 8; depth++ {
+		switch {
+		case t.Implements(jsonAppenderType):
+			return true
+		case t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType):
+			return false
+		case t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+			t = t.Elem()
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// appendJSONReflect encodes a value of a jsonAppendable type, addressable
+// elements are passed by pointer so they aren't boxed one by one
+func appendJSONReflect(dst []byte, rv reflect.Value) ([]byte, error) {
+	switch {
+	case rv.Kind() == reflect.Pointer && rv.IsNil(), rv.Kind() == reflect.Slice && rv.IsNil():
+		return append(dst, "null"...), nil
+	case rv.Type().Implements(jsonAppenderType):
+		if rv.Kind() != reflect.Pointer && rv.CanAddr() {
+			rv = rv.Addr()
+		}
+		return rv.Interface().(jsonAppender).AppendJSON(dst)
+	case rv.Kind() == reflect.Pointer:
+		return appendJSONReflect(dst, rv.Elem())
+	}
+
+	var err error
+	dst = append(dst, '[')
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst, err = appendJSONReflect(dst, rv.Index(i))
+		if err != nil {
+			return dst, err
+		}
+	}
+
+	return append(dst, ']'), nil
+}
+
+func (resp response) AppendJSON(dst []byte) ([]byte, error) {
+	dst = append(dst, '{')
+	if resp.Response != nil {
+		var err error
+		dst = append(dst, "\"response\":"...)
+		dst, err = appendJSONValue(dst, resp.Response)
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, ',')
+	}
+
+	dst = append(dst, "\"error\":"...)
+	dst = appendJSONString(dst, resp.Error)
+	return append(dst, '}'), nil
+}
+`
+
+// jsonField is an exported struct field as encoding/json sees it
+type jsonField struct {
+	Name      string
+	Key       string
+	Type      ast.Expr
+	OmitEmpty bool
+}
+
+var jsonIntTypes = map[string]bool{"int": true, "int8": true, "int16": true, "int32": true, "int64": true}
+
+var jsonUintTypes = map[string]bool{"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true}
+
+// resultTypeName returns T for results like T and *T
+func resultTypeName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+
+	return ident.Name
+}
+
+// getStructTypes returns non generic struct declarations of the file
+func getStructTypes(node *ast.File) map[string]*ast.StructType {
+	structs := make(map[string]*ast.StructType)
+
+	for _, decl := range node.Decls {
+		g, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range g.Specs {
+			currType, ok := spec.(*ast.TypeSpec)
+			if !ok || currType.TypeParams != nil {
+				continue
+			}
+
+			if currStruct, ok := currType.Type.(*ast.StructType); ok {
+				structs[currType.Name.Name] = currStruct
+			}
+		}
+	}
+
+	return structs
+}
+
+// getMarshalers returns types that already encode themselves
+func getMarshalers(node *ast.File) map[string]bool {
+	marshalers := make(map[string]bool)
+
+	for _, decl := range node.Decls {
+		f, ok := decl.(*ast.FuncDecl)
+		if !ok || f.Recv == nil || len(f.Recv.List) == 0 {
+			continue
+		}
+
+		switch f.Name.Name {
+		case "MarshalJSON", "AppendJSON", "MarshalText":
+			marshalers[resultTypeName(f.Recv.List[0].Type)] = true
+		}
+	}
+
+	return marshalers
+}
+
+// getJSONFields returns fields in encoding order or false when the struct
+// uses something the generated encoder doesn't reproduce: embedded fields,
+// ",string", "omitzero" or omitempty of a type we can't check for emptiness
+func getJSONFields(currStruct *ast.StructType) ([]jsonField, bool) {
+	fields := make([]jsonField, 0)
+	keys := make(map[string]bool)
+
+	for _, field := range currStruct.Fields.List {
+		if len(field.Names) == 0 {
+			return nil, false
+		}
+
+		tag := ""
+		if field.Tag != nil {
+			unquoted, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, false
+			}
+			tag = reflect.StructTag(unquoted).Get("json")
+		}
+
+		if tag == "-" {
+			continue
+		}
+
+		key, options, _ := strings.Cut(tag, ",")
+		omitEmpty := false
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "":
+			case "omitempty":
+				omitEmpty = true
+			default:
+				return nil, false
+			}
+		}
+
+		if omitEmpty && emptyCheck("v", field.Type) == "" && !isStructType(field.Type) {
+			return nil, false
+		}
+
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+
+			fieldKey := key
+			if fieldKey == "" {
+				fieldKey = name.Name
+			}
+			if keys[fieldKey] {
+				return nil, false
+			}
+			keys[fieldKey] = true
+
+			fields = append(fields, jsonField{
+				Name:      name.Name,
+				Key:       fieldKey,
+				Type:      field.Type,
+				OmitEmpty: omitEmpty,
+			})
+		}
+	}
+
+	return fields, true
+}
+
+// isStructType is true for named types which are never empty for omitempty
+// as far as we know, that is structs declared in the parsed file
+func isStructType(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Obj != nil && ident.Obj.Kind == ast.Typ && isStructSpec(ident.Obj.Decl)
+}
+
+func isStructSpec(decl any) bool {
+	spec, ok := decl.(*ast.TypeSpec)
+	if !ok {
+		return false
+	}
+	_, ok = spec.Type.(*ast.StructType)
+	return ok
+}
+
+// emptyCheck returns the condition of a non empty value for omitempty
+func emptyCheck(expr string, typ ast.Expr) string {
+	switch t := typ.(type) {
+	case *ast.Ident:
+		switch {
+		case t.Name == "string":
+			return expr + ` != ""`
+		case t.Name == "bool":
+			return expr
+		case jsonIntTypes[t.Name], jsonUintTypes[t.Name], t.Name == "float32", t.Name == "float64":
+			return expr + " != 0"
+		case t.Name == "any", t.Name == "error":
+			return expr + " != nil"
+		}
+	case *ast.StarExpr, *ast.InterfaceType:
+		return expr + " != nil"
+	case *ast.ArrayType, *ast.MapType:
+		return "len(" + expr + ") != 0"
+	}
+
+	return ""
+}
+
+// getJSONEncoderTypes returns result types of the annotated methods and
+// structs nested in them which can get generated encoders
+func getJSONEncoderTypes(node *ast.File, funcs []GeneratedFunc) ([]string, map[string][]jsonField) {
+	structs := getStructTypes(node)
+	marshalers := getMarshalers(node)
+
+	names := make([]string, 0)
+	encoders := make(map[string][]jsonField)
+	visited := make(map[string]bool)
+
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+
+		currStruct, ok := structs[name]
+		if !ok || marshalers[name] {
+			return
+		}
+
+		fields, ok := getJSONFields(currStruct)
+		if !ok {
+			return
+		}
+
+		names = append(names, name)
+		encoders[name] = fields
+
+		for _, field := range fields {
+			ast.Inspect(field.Type, func(n ast.Node) bool {
+				if ident, ok := n.(*ast.Ident); ok {
+					visit(ident.Name)
+				}
+				return true
+			})
+		}
+	}
+
+	for _, f := range funcs {
+		if f.ResultTypeName != "" {
+			visit(f.ResultTypeName)
+		}
+	}
+
+	return names, encoders
+}
+
+type jsonEncoderGen struct {
+	encoders map[string][]jsonField
+	body     strings.Builder
+	usesErr  bool
+}
+
+func (g *jsonEncoderGen) line(indent int, format string, args ...any) {
+	g.body.WriteString(strings.Repeat("\t", indent))
+	fmt.Fprintf(&g.body, format, args...)
+	g.body.WriteString("\n")
+}
+
+func (g *jsonEncoderGen) checkErr(indent int) {
+	g.usesErr = true
+	g.line(indent, "if err != nil {")
+	g.line(indent+1, "return dst, err")
+	g.line(indent, "}")
+}
+
+func (g *jsonEncoderGen) value(indent, depth int, expr string, typ ast.Expr) {
+	switch t := typ.(type) {
+	case *ast.Ident:
+		switch {
+		case t.Name == "string":
+			g.line(indent, "dst = appendJSONString(dst, %s)", expr)
+			return
+		case t.Name == "bool":
+			g.line(indent, "dst = strconv.AppendBool(dst, %s)", expr)
+			return
+		case jsonIntTypes[t.Name]:
+			g.line(indent, "dst = strconv.AppendInt(dst, int64(%s), 10)", expr)
+			return
+		case jsonUintTypes[t.Name]:
+			g.line(indent, "dst = strconv.AppendUint(dst, uint64(%s), 10)", expr)
+			return
+		case t.Name == "float32" || t.Name == "float64":
+			g.line(indent, "dst, err = appendJSONFloat(dst, float64(%s), %s)", expr, strings.TrimPrefix(t.Name, "float"))
+			g.checkErr(indent)
+			return
+		case g.encoders[t.Name] != nil:
+			g.line(indent, "dst, err = %s.AppendJSON(dst)", expr)
+			g.checkErr(indent)
+			return
+		}
+	case *ast.StarExpr:
+		g.line(indent, "if %s == nil {", expr)
+		g.line(indent+1, `dst = append(dst, "null"...)`)
+		g.line(indent, "} else {")
+		g.value(indent+1, depth, "(*"+expr+")", t.X)
+		g.line(indent, "}")
+		return
+	case *ast.ArrayType:
+		if elem, ok := t.Elt.(*ast.Ident); ok && (elem.Name == "byte" || elem.Name == "uint8") {
+			break
+		}
+
+		index := fmt.Sprintf("i%d", depth)
+		inner := indent
+		if t.Len == nil {
+			g.line(indent, "if %s == nil {", expr)
+			g.line(indent+1, `dst = append(dst, "null"...)`)
+			g.line(indent, "} else {")
+			inner++
+		}
+		g.line(inner, "dst = append(dst, '[')")
+		g.line(inner, "for %s := range %s {", index, expr)
+		g.line(inner+1, "if %s > 0 {", index)
+		g.line(inner+2, "dst = append(dst, ',')")
+		g.line(inner+1, "}")
+		g.value(inner+1, depth+1, expr+"["+index+"]", t.Elt)
+		g.line(inner, "}")
+		g.line(inner, "dst = append(dst, ']')")
+		if t.Len == nil {
+			g.line(indent, "}")
+		}
+		return
+	}
+
+	g.line(indent, "dst, err = appendJSONValue(dst, %s)", expr)
+	g.checkErr(indent)
+}
+
+// generateJSONEncoders emits AppendJSON for the result types,
+// fields are written with a leading comma and the first one is replaced by
+// the opening brace, so omitempty needs no bookkeeping
+func generateJSONEncoders(out io.Writer, names []string, encoders map[string][]jsonField) {
+	for _, name := range names {
+		g := &jsonEncoderGen{encoders: encoders}
+
+		for _, field := range encoders[name] {
+			key, _ := json.Marshal(field.Key)
+			expr := "v." + field.Name
+
+			indent := 1
+			if field.OmitEmpty && !isStructType(field.Type) {
+				g.line(indent, "if %s {", emptyCheck(expr, field.Type))
+				indent++
+			}
+			g.line(indent, "dst = append(dst, %s...)", strconv.Quote(","+string(key)+":"))
+			g.value(indent, 0, expr, field.Type)
+			if indent > 1 {
+				g.line(1, "}")
+			}
+		}
+
+		fmt.Fprintf(out, "\n// AppendJSON appends %s encoded like encoding/json does without reflection\n", name)
+		fmt.Fprintf(out, "func (v %s) AppendJSON(dst []byte) ([]byte, error) {\n", name)
+		if g.usesErr {
+			fmt.Fprintln(out, "\tvar err error")
+		}
+		fmt.Fprintln(out, "\tstart := len(dst)")
+		fmt.Fprint(out, g.body.String())
+		fmt.Fprintln(out, "\tif len(dst) == start {")
+		fmt.Fprintln(out, "\t\tdst = append(dst, '{')")
+		fmt.Fprintln(out, "\t} else {")
+		fmt.Fprintln(out, "\t\tdst[start] = '{'")
+		fmt.Fprintln(out, "\t}")
+		fmt.Fprintln(out, "\treturn append(dst, '}'), nil")
+		fmt.Fprintln(out, "}")
+	}
+}
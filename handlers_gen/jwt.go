@@ -0,0 +1,183 @@
+package main
+
+// jwtRuntime verifies HS256/HS512 bearer tokens for "auth": "jwt" with the
+// standard library only
+var jwtRuntime = `
+var (
+	errJWTNoSecret  = errors.New("token secret is not set")
+	errJWTMalformed = errors.New("malformed token")
+	errJWTAlgorithm = errors.New("unsupported token algorithm")
+	errJWTSignature = errors.New("bad token signature")
+	errJWTExpired   = errors.New("token expired")
+	errJWTNotBefore = errors.New("token not valid yet")
+	errJWTAudience  = errors.New("token audience mismatch")
+)
+
+type jwtClaimsCtxKey struct{}
+
+// ClaimsFromContext returns claims of the verified JWT
+func ClaimsFromContext(ctx context.Context) (map[string]any, bool) {
+	claims, ok := ctx.Value(jwtClaimsCtxKey{}).(map[string]any)
+	return claims, ok
+}
+
+// JWTAuthenticator checks "Authorization: Bearer <token>" signed with HS256 or HS512.
+// Principal.ID is taken from "sub" and Principal.Roles from "roles"
+type JWTAuthenticator struct {
+	Secret []byte
+	// Audience is required in "aud" when not empty
+	Audience string
+	// Leeway is allowed clock skew for "exp" and "nbf"
+	Leeway time.Duration
+	Now    func() time.Time
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (context.Context, error) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return nil, errUnauthorized
+	}
+
+	claims, err := a.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := Principal{}
+	principal.ID, _ = claims["sub"].(string)
+	roles, _ := claims["roles"].([]any)
+	for _, role := range roles {
+		if role, ok := role.(string); ok {
+			principal.Roles = append(principal.Roles, role)
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), jwtClaimsCtxKey{}, claims)
+	return ContextWithPrincipal(ctx, principal), nil
+}
+
+// Verify checks the signature and the registered time and audience claims,
+// every token is rejected without Secret
+func (a *JWTAuthenticator) Verify(token string) (map[string]any, error) {
+	if len(a.Secret) == 0 {
+		return nil, errJWTNoSecret
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errJWTMalformed
+	}
+
+	header := struct {
+		Alg string ` + "`json:\"alg\"`" + `
+	}{}
+	if err := jwtDecodePart(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	var newHash func() hash.Hash
+	switch header.Alg {
+	case "HS256":
+		newHash = sha256.New
+	case "HS512":
+		newHash = sha512.New
+	default:
+		return nil, errJWTAlgorithm
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errJWTMalformed
+	}
+
+	mac := hmac.New(newHash, a.Secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errJWTSignature
+	}
+
+	claims := map[string]any{}
+	if err := jwtDecodePart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if a.Now != nil {
+		now = a.Now()
+	}
+
+	exp, ok, err := jwtTime(claims, "exp")
+	if err != nil {
+		return nil, err
+	}
+	if ok && !now.Before(exp.Add(a.Leeway)) {
+		return nil, errJWTExpired
+	}
+
+	nbf, ok, err := jwtTime(claims, "nbf")
+	if err != nil {
+		return nil, err
+	}
+	if ok && now.Add(a.Leeway).Before(nbf) {
+		return nil, errJWTNotBefore
+	}
+
+	if a.Audience != "" && !jwtHasAudience(claims["aud"], a.Audience) {
+		return nil, errJWTAudience
+	}
+
+	return claims, nil
+}
+
+func jwtDecodePart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errJWTMalformed
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return errJWTMalformed
+	}
+
+	return nil
+}
+
+// jwtTime reads the NumericDate claim name, it is not ok when the claim is
+// absent and malformed when it is anything but a number of seconds
+func jwtTime(claims map[string]any, name string) (time.Time, bool, error) {
+	claim, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	number, ok := claim.(json.Number)
+	if !ok {
+		return time.Time{}, false, errJWTMalformed
+	}
+
+	seconds, err := number.Float64()
+	if err != nil || math.Abs(seconds) > 1<<62 {
+		return time.Time{}, false, errJWTMalformed
+	}
+
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))), true, nil
+}
+
+func jwtHasAudience(claim any, audience string) bool {
+	switch aud := claim.(type) {
+	case string:
+		return aud == audience
+	case []any:
+		for _, item := range aud {
+			if item == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+`
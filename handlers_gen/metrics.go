@@ -0,0 +1,228 @@
+package main
+
+// metricsRuntime measures every generated endpoint through ApiConfig.Metrics
+var metricsRuntime = `
+// MetricsRecorder gets measurements of generated endpoints labeled by
+// receiver type and method name
+type MetricsRecorder interface {
+	// Start is called when the request is accepted by the endpoint
+	Start(receiver, method string)
+	// Done is called with the written status after the handler returns
+	Done(receiver, method string, status int, duration time.Duration)
+}
+
+// apiStatusWriter remembers the status, Flush and Unwrap keep streaming and
+// http.ResponseController working through it
+type apiStatusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *apiStatusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *apiStatusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *apiStatusWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *apiStatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *apiStatusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func apiObserveNothing() {}
+
+// observe starts measuring, logging and tracing the request, the returned
+// func must be deferred before recoverPanic so 500 of recovered panics is seen
+func (cfg *ApiConfig) observe(w http.ResponseWriter, r *http.Request, endpoint *ApiEndpoint) (http.ResponseWriter, *http.Request, func()) {
+	metrics, logger, tracer := cfg.Metrics, cfg.AccessLogger, cfg.tracer()
+	if metrics == nil && logger == nil && tracer == nil {
+		return w, r, apiObserveNothing
+	}
+
+	start := time.Now()
+	sw := &apiStatusWriter{ResponseWriter: w}
+	ctx := r.Context()
+
+	var info *apiRequestInfo
+	if logger != nil {
+		info = &apiRequestInfo{requestID: r.Header.Get("X-Request-ID")}
+		if info.requestID == "" || len(info.requestID) > 128 {
+			info.requestID = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", info.requestID)
+		ctx = context.WithValue(ctx, apiRequestInfoCtxKey{}, info)
+	}
+
+	var span Span
+	if tracer != nil {
+		ctx, span = tracer.Start(ctx, endpoint.Receiver+"."+endpoint.Method, endpoint.spanAttrs()...)
+	}
+
+	if ctx != r.Context() {
+		r = r.WithContext(ctx)
+	}
+
+	if metrics != nil {
+		metrics.Start(endpoint.Receiver, endpoint.Method)
+	}
+
+	return sw, r, func() {
+		latency := time.Since(start)
+		if metrics != nil {
+			metrics.Done(endpoint.Receiver, endpoint.Method, sw.Status(), latency)
+		}
+		if logger != nil {
+			cfg.logAccess(r, endpoint, info, sw.Status(), latency)
+		}
+		if span != nil {
+			var err error
+			if sw.Status() >= http.StatusInternalServerError {
+				err = errors.New(http.StatusText(sw.Status()))
+			}
+			span.SetAttributes(SpanAttr{"http.status_code", sw.Status()})
+			span.End(err)
+		}
+	}
+}
+`
+
+// prometheusRuntime is emitted with -prometheus, PrometheusMetrics is the
+// recorder exposing the text format
+var prometheusRuntime = `
+// DefaultLatencyBuckets are upper bounds of the latency histogram in seconds
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type apiEndpointKey struct {
+	receiver string
+	method   string
+}
+
+type apiEndpointMetrics struct {
+	mu       sync.Mutex
+	inFlight int64
+	statuses map[int]uint64
+	buckets  []uint64
+	count    uint64
+	sum      float64
+}
+
+// PrometheusMetrics counts requests by status, observes latency and in-flight
+// requests of every endpoint and serves them in the Prometheus text format
+type PrometheusMetrics struct {
+	// Buckets must not be changed after the first request
+	Buckets []float64
+
+	endpoints sync.Map
+}
+
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{Buckets: DefaultLatencyBuckets}
+}
+
+func (m *PrometheusMetrics) endpoint(receiver, method string) *apiEndpointMetrics {
+	key := apiEndpointKey{receiver, method}
+	if endpoint, ok := m.endpoints.Load(key); ok {
+		return endpoint.(*apiEndpointMetrics)
+	}
+
+	endpoint, _ := m.endpoints.LoadOrStore(key, &apiEndpointMetrics{
+		statuses: map[int]uint64{},
+		buckets:  make([]uint64, len(m.Buckets)),
+	})
+	return endpoint.(*apiEndpointMetrics)
+}
+
+func (m *PrometheusMetrics) Start(receiver, method string) {
+	endpoint := m.endpoint(receiver, method)
+	endpoint.mu.Lock()
+	endpoint.inFlight++
+	endpoint.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) Done(receiver, method string, status int, duration time.Duration) {
+	seconds := duration.Seconds()
+	endpoint := m.endpoint(receiver, method)
+
+	endpoint.mu.Lock()
+	defer endpoint.mu.Unlock()
+
+	endpoint.inFlight--
+	endpoint.statuses[status]++
+	endpoint.count++
+	endpoint.sum += seconds
+	for i, bound := range m.Buckets {
+		if seconds <= bound {
+			endpoint.buckets[i]++
+		}
+	}
+}
+
+// ServeHTTP writes metrics in the Prometheus text exposition format
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	keys := make([]apiEndpointKey, 0)
+	m.endpoints.Range(func(key, _ any) bool {
+		keys = append(keys, key.(apiEndpointKey))
+		return true
+	})
+	slices.SortFunc(keys, func(a, b apiEndpointKey) int {
+		return strings.Compare(a.receiver+"."+a.method, b.receiver+"."+b.method)
+	})
+
+	var requests, latency, inFlight bytes.Buffer
+	requests.WriteString("# HELP apigen_requests_total Requests served by generated handlers.\n# TYPE apigen_requests_total counter\n")
+	latency.WriteString("# HELP apigen_request_duration_seconds Latency of generated handlers.\n# TYPE apigen_request_duration_seconds histogram\n")
+	inFlight.WriteString("# HELP apigen_requests_in_flight Requests being served by generated handlers.\n# TYPE apigen_requests_in_flight gauge\n")
+
+	for _, key := range keys {
+		value, _ := m.endpoints.Load(key)
+		endpoint := value.(*apiEndpointMetrics)
+		labels := fmt.Sprintf("receiver=%q,method=%q", key.receiver, key.method)
+
+		endpoint.mu.Lock()
+		statuses := make([]int, 0, len(endpoint.statuses))
+		for status := range endpoint.statuses {
+			statuses = append(statuses, status)
+		}
+		slices.Sort(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(&requests, "apigen_requests_total{%s,status=\"%d\"} %d\n", labels, status, endpoint.statuses[status])
+		}
+
+		for i, bound := range m.Buckets {
+			fmt.Fprintf(&latency, "apigen_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), endpoint.buckets[i])
+		}
+		fmt.Fprintf(&latency, "apigen_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, endpoint.count)
+		fmt.Fprintf(&latency, "apigen_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(endpoint.sum, 'g', -1, 64))
+		fmt.Fprintf(&latency, "apigen_request_duration_seconds_count{%s} %d\n", labels, endpoint.count)
+
+		fmt.Fprintf(&inFlight, "apigen_requests_in_flight{%s} %d\n", labels, endpoint.inFlight)
+		endpoint.mu.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	requests.WriteTo(w)
+	latency.WriteTo(w)
+	inFlight.WriteTo(w)
+}
+`
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+	"strings"
+	"text/template"
+)
+
+var apiGenMiddlewarePrefix = "// apigen:middleware "
+
+type middleware struct {
+	Name     string
+	FuncName string
+}
+
+// getMiddlewares reads functions like
+//
+//	// apigen:middleware audit
+//	func audit(next http.Handler) http.Handler
+func getMiddlewares(node *ast.File) ([]middleware, error) {
+	middlewares := make([]middleware, 0)
+	seen := make(map[string]string)
+
+	for _, decl := range node.Decls {
+		f, ok := decl.(*ast.FuncDecl)
+		if !ok || f.Doc == nil {
+			continue
+		}
+
+		for _, comment := range f.Doc.List {
+			name, found := strings.CutPrefix(comment.Text, apiGenMiddlewarePrefix)
+			if !found {
+				continue
+			}
+
+			name = strings.TrimSpace(name)
+			if f.Recv != nil || f.Type.Params.NumFields() != 1 || f.Type.Results.NumFields() != 1 {
+				return nil, fmt.Errorf("apigen:middleware %s: %s must be func(http.Handler) http.Handler", name, f.Name.Name)
+			}
+			if other, ok := seen[name]; ok {
+				return nil, fmt.Errorf("apigen:middleware %s is declared by %s and %s", name, other, f.Name.Name)
+			}
+			seen[name] = f.Name.Name
+
+			middlewares = append(middlewares, middleware{Name: name, FuncName: f.Name.Name})
+		}
+	}
+
+	return middlewares, nil
+}
+
+// checkMiddlewares reports "middleware" names without apigen:middleware functions
+func checkMiddlewares(funcs []GeneratedFunc, middlewares []middleware) error {
+	registered := make(map[string]bool)
+	for _, m := range middlewares {
+		registered[m.Name] = true
+	}
+
+	for _, f := range funcs {
+		for _, name := range f.Middleware {
+			if !registered[name] {
+				return fmt.Errorf("%s.%s: middleware %q is not registered with // apigen:middleware", f.ReceiverTypeName, f.FuncName, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+var middlewaresTemplate = template.Must(template.New("middlewaresTempl").Parse(`
+// registerMiddlewares adds functions declared with apigen:middleware
+func registerMiddlewares(cfg *ApiConfig) {
+	{{- range .}}
+	cfg.Middlewares[{{printf "%q" .Name}}] = {{.FuncName}}
+	{{- end}}
+}
+`))
+
+func generateMiddlewares(out io.Writer, middlewares []middleware) error {
+	return middlewaresTemplate.Execute(out, middlewares)
+}
+
+type middlewareHandlerTempl struct {
+	ReceiverTypeName string
+	FuncName         string
+	EndpointVar      string
+	Middleware       []string
+}
+
+// middlewareHandlerTemplate is handler$Method of endpoints with middleware,
+// the generated code goes to serve$Method
+var middlewareHandlerTemplate = template.Must(template.New("middlewareHandlerTempl").Parse(`func (h *{{.ReceiverTypeName}}) handler{{.FuncName}}(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+	w, r, done := cfg.observe(w, r, {{.EndpointVar}})
+	defer done()
+	defer cfg.recoverPanic(w, r)
+
+	cfg.wrap(func(w http.ResponseWriter, r *http.Request) {
+		h.serve{{.FuncName}}(w, r, cfg)
+	}{{range .Middleware}}, {{printf "%q" .}}{{end}}).ServeHTTP(w, r)
+}
+
+`))
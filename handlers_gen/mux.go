@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"text/template"
+)
+
+type muxRouteTempl struct {
+	Pattern string
+	Handler string
+}
+
+type muxTempl struct {
+	TypeName   string
+	ConfigExpr string
+	Routes     []muxRouteTempl
+}
+
+// muxPattern builds a Go 1.22 http.ServeMux pattern like "POST /user/create"
+func muxPattern(f GeneratedFunc, path string) string {
+	if method := f.HttpMethod(); method != "" {
+		return method + " " + path
+	}
+
+	return path
+}
+
+func receiverMuxTempl(receiver string, funcs []GeneratedFunc) muxTempl {
+	templ := muxTempl{TypeName: receiver, ConfigExpr: "DefaultApiConfig"}
+	for _, f := range funcs {
+		templ.Routes = append(templ.Routes, muxRouteTempl{
+			Pattern: muxPattern(f, f.Url),
+			Handler: fmt.Sprintf("cfg.bind(h.handler%s)", f.FuncName),
+		})
+	}
+
+	return templ
+}
+
+func routerMuxTempl(routerName string, routes []routerRoute) muxTempl {
+	templ := muxTempl{TypeName: routerName, ConfigExpr: "h.apiConfig()"}
+	for _, route := range routes {
+		templ.Routes = append(templ.Routes, muxRouteTempl{
+			Pattern: muxPattern(route.GeneratedFunc, route.Path),
+			Handler: fmt.Sprintf("cfg.bind(h.%s.handler%s)", route.ReceiverTypeName, route.FuncName),
+		})
+	}
+
+	return templ
+}
+
+var muxRegisterTemplate = template.Must(template.New("muxRegisterTempl").Parse(`
+// Register adds the routes of {{.TypeName}} to mux bound to {{.ConfigExpr}}
+func (h *{{.TypeName}}) Register(mux *http.ServeMux) {
+	h.register(mux, {{.ConfigExpr}})
+}
+
+func (h *{{.TypeName}}) register(mux *http.ServeMux, cfg *ApiConfig) {
+	{{- range .Routes}}
+	mux.HandleFunc("{{.Pattern}}", {{.Handler}})
+	{{- end}}
+}
+
+`))
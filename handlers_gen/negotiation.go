@@ -0,0 +1,160 @@
+package main
+
+// negotiationRuntime picks the response media type from the Accept header,
+// JSON is built in and always preferred, XML and RegisterEncoder types follow
+var negotiationRuntime = `
+const (
+	jsonMediaType = "application/json"
+	xmlMediaType  = "application/xml"
+)
+
+// Encoder writes response bodies of one media type
+type Encoder interface {
+	Encode(w io.Writer, v any) error
+}
+
+// EncoderFunc adapts a function to Encoder
+type EncoderFunc func(w io.Writer, v any) error
+
+func (f EncoderFunc) Encode(w io.Writer, v any) error {
+	return f(w, v)
+}
+
+// XMLEncoder writes the XML declaration and v with encoding/xml
+var XMLEncoder = EncoderFunc(func(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	return xml.NewEncoder(w).Encode(v)
+})
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{
+		xmlMediaType: XMLEncoder,
+		"text/xml":   XMLEncoder,
+	}
+	// encoderMediaTypes are offered in this order when Accept weights are equal
+	encoderMediaTypes = []string{jsonMediaType, xmlMediaType, "text/xml"}
+)
+
+// RegisterEncoder makes responses available in mediaType, e.g. a binary
+// format for "application/x-msgpack". application/json can't be replaced
+func RegisterEncoder(mediaType string, encoder Encoder) {
+	mediaType = strings.ToLower(mediaType)
+	if mediaType == jsonMediaType {
+		panic("apigen: application/json encoder is built in")
+	}
+
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	if _, ok := encoders[mediaType]; !ok {
+		encoderMediaTypes = append(encoderMediaTypes, mediaType)
+	}
+	encoders[mediaType] = encoder
+}
+
+// lookupEncoder returns the encoder of mediaType, structured syntax suffixes
+// like application/problem+xml use the encoder of their suffix
+func lookupEncoder(mediaType string) Encoder {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	if encoder, ok := encoders[mediaType]; ok {
+		return encoder
+	}
+	if strings.HasSuffix(mediaType, "+xml") {
+		return encoders[xmlMediaType]
+	}
+
+	return nil
+}
+
+// mediaTypeOf returns the lower case media type of a Content-Type without params
+func mediaTypeOf(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "" || mediaType == jsonMediaType || strings.HasSuffix(mediaType, "+json")
+}
+
+var varyAccept = []string{"Accept"}
+
+// negotiate stores the media type chosen by Accept in Content-Type for
+// writeResponse and writeError. It reports false when nothing offered is
+// acceptable, Content-Type stays JSON for the 406 the handler answers after
+// its method and auth checks
+func negotiate(w http.ResponseWriter, r *http.Request) bool {
+	header := w.Header()
+	if _, ok := header["Vary"]; !ok {
+		header["Vary"] = varyAccept
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return true
+	}
+
+	encodersMu.RLock()
+	mediaType := negotiateMediaType(accept, encoderMediaTypes)
+	encodersMu.RUnlock()
+
+	switch mediaType {
+	case "":
+		return false
+	case jsonMediaType:
+		return true
+	}
+
+	header.Set("Content-Type", mediaType)
+	return true
+}
+
+// negotiateMediaType returns the offered media type with the highest
+// quality in accept, the most specific media range sets the quality
+func negotiateMediaType(accept string, offered []string) string {
+	best, bestQuality := "", 0.0
+	for _, offer := range offered {
+		quality, specificity := 0.0, -1
+		for _, mediaRange := range strings.Split(accept, ",") {
+			rangeType, params, _ := strings.Cut(mediaRange, ";")
+			rangeType = strings.ToLower(strings.TrimSpace(rangeType))
+
+			rangeSpecificity := 0
+			switch {
+			case rangeType == offer:
+				rangeSpecificity = 2
+			case strings.HasSuffix(rangeType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(rangeType, "*")):
+				rangeSpecificity = 1
+			case rangeType == "*/*" || rangeType == "*":
+			default:
+				continue
+			}
+			if rangeSpecificity <= specificity {
+				continue
+			}
+
+			specificity, quality = rangeSpecificity, 1
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(param, "=")
+				if strings.TrimSpace(name) != "q" {
+					continue
+				}
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+
+	return best
+}
+`
@@ -0,0 +1,61 @@
+package main
+
+const (
+	errorsClassic = "classic"
+	errorsProblem = "problem"
+)
+
+var classicErrorRuntime = `
+// writeError answers with {"error": message}, invalidParams name the
+// request params which failed validation
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string, invalidParams ...string) {
+	writeResponse(w, r, status, response{Error: message})
+}
+`
+
+// problemErrorRuntime renders errors as RFC 7807 problem details
+var problemErrorRuntime = `
+type problemInvalidParam struct {
+	Name   string ` + "`json:\"name\" xml:\"name\"`" + `
+	Reason string ` + "`json:\"reason\" xml:\"reason\"`" + `
+}
+
+// problemDetails is rendered as application/problem+json or, with XML
+// negotiated, as application/problem+xml from RFC 7807 appendix A
+type problemDetails struct {
+	XMLName       xml.Name              ` + "`json:\"-\" xml:\"urn:ietf:rfc:7807 problem\"`" + `
+	Type          string                ` + "`json:\"type\" xml:\"type\"`" + `
+	Title         string                ` + "`json:\"title\" xml:\"title\"`" + `
+	Status        int                   ` + "`json:\"status\" xml:\"status\"`" + `
+	Detail        string                ` + "`json:\"detail,omitempty\" xml:\"detail,omitempty\"`" + `
+	Instance      string                ` + "`json:\"instance,omitempty\" xml:\"instance,omitempty\"`" + `
+	InvalidParams []problemInvalidParam ` + "`json:\"invalid-params,omitempty\" xml:\"invalid-params>i,omitempty\"`" + `
+}
+
+// writeError answers with problem details, invalidParams name the
+// request params which failed validation
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string, invalidParams ...string) {
+	problem := problemDetails{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   message,
+		Instance: r.URL.Path,
+	}
+	for _, name := range invalidParams {
+		problem.InvalidParams = append(problem.InvalidParams, problemInvalidParam{
+			Name:   name,
+			Reason: message,
+		})
+	}
+
+	header := w.Header()
+	switch mediaType := mediaTypeOf(header.Get("Content-Type")); {
+	case isJSONMediaType(mediaType):
+		header.Set("Content-Type", "application/problem+json")
+	case mediaType == xmlMediaType || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		header.Set("Content-Type", "application/problem+xml")
+	}
+	writeResponse(w, r, status, problem)
+}
+`
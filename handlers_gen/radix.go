@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"text/template"
+)
+
+type radixRouteTempl struct {
+	Path    string
+	Handler string
+}
+
+type radixTempl struct {
+	TypeName   string
+	ConfigExpr string
+	Routes     []radixRouteTempl
+}
+
+func receiverRadixTempl(receiver string, funcs []GeneratedFunc) radixTempl {
+	templ := radixTempl{TypeName: receiver, ConfigExpr: "DefaultApiConfig"}
+	for _, f := range funcs {
+		templ.Routes = append(templ.Routes, radixRouteTempl{
+			Path:    f.Url,
+			Handler: fmt.Sprintf("(*%s).handler%s", receiver, f.FuncName),
+		})
+	}
+
+	return templ
+}
+
+func routerRadixTempl(routerName string, routes []routerRoute) radixTempl {
+	templ := radixTempl{TypeName: routerName, ConfigExpr: "h.apiConfig()"}
+	for _, route := range routes {
+		templ.Routes = append(templ.Routes, radixRouteTempl{
+			Path: route.Path,
+			Handler: fmt.Sprintf("func(rt *%s, w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {\n\t\trt.%s.handler%s(w, r, cfg)\n\t}",
+				routerName, route.ReceiverTypeName, route.FuncName),
+		})
+	}
+
+	return templ
+}
+
+var (
+	radixServeHTTPTemplate = template.Must(template.New("radixServeHTTPTempl").Parse(`
+var radix{{.TypeName}} = newApiRadixTree(
+	{{- range .Routes}}
+	apiRadixRoute[{{$.TypeName}}]{"{{.Path}}", {{.Handler}}},
+	{{- end}}
+)
+
+// ServeHTTP serves {{.TypeName}} with {{.ConfigExpr}}
+func (h *{{.TypeName}}) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.serveApi(w, r, {{.ConfigExpr}})
+}
+
+func (h *{{.TypeName}}) serveApi(w http.ResponseWriter, r *http.Request, cfg *ApiConfig) {
+	var buf [4]apiPathParam
+
+	handler, params := radix{{.TypeName}}.lookup(r.URL.Path, buf[:0])
+	if handler == nil {
+		writeError(w, r, http.StatusNotFound, "unknown method")
+		return
+	}
+
+	for _, p := range params {
+		r.SetPathValue(p.Name, p.Value)
+	}
+
+	handler(h, w, r, cfg)
+}
+
+`))
+
+	radixRuntime = `
+type apiPathParam struct {
+	Name  string
+	Value string
+}
+
+type apiRadixRoute[T any] struct {
+	Pattern string
+	Handler func(*T, http.ResponseWriter, *http.Request, *ApiConfig)
+}
+
+// apiRadixNode is a node of the route tree. Static nodes match their prefix,
+// param nodes match one path segment. Routes may name the same param node
+// differently, so names are kept with the handler
+type apiRadixNode[T any] struct {
+	prefix   string
+	param    bool
+	handler  func(*T, http.ResponseWriter, *http.Request, *ApiConfig)
+	names    []string
+	indices  string
+	children []*apiRadixNode[T]
+	wildcard *apiRadixNode[T]
+}
+
+func newApiRadixTree[T any](routes ...apiRadixRoute[T]) *apiRadixNode[T] {
+	root := &apiRadixNode[T]{}
+	for _, route := range routes {
+		root.add(route.Pattern, route.Handler)
+	}
+
+	return root
+}
+
+func (n *apiRadixNode[T]) add(path string, handler func(*T, http.ResponseWriter, *http.Request, *ApiConfig)) {
+	var names []string
+	for path != "" {
+		if path[0] == '{' {
+			end := strings.IndexByte(path, '}')
+			if n.wildcard == nil {
+				n.wildcard = &apiRadixNode[T]{param: true}
+			}
+			names = append(names, path[1:end])
+			n, path = n.wildcard, path[end+1:]
+			continue
+		}
+
+		static := path
+		if end := strings.IndexByte(path, '{'); end >= 0 {
+			static = path[:end]
+		}
+
+		idx := strings.IndexByte(n.indices, static[0])
+		if idx < 0 {
+			child := &apiRadixNode[T]{prefix: static}
+			n.indices += static[:1]
+			n.children = append(n.children, child)
+			n, path = child, path[len(static):]
+			continue
+		}
+
+		child := n.children[idx]
+		common := 0
+		for common < len(child.prefix) && common < len(static) && child.prefix[common] == static[common] {
+			common++
+		}
+
+		if common < len(child.prefix) {
+			tail := *child
+			tail.prefix = child.prefix[common:]
+			*child = apiRadixNode[T]{
+				prefix:   child.prefix[:common],
+				indices:  tail.prefix[:1],
+				children: []*apiRadixNode[T]{&tail},
+			}
+		}
+
+		n, path = child, path[common:]
+	}
+
+	n.handler, n.names = handler, names
+}
+
+// lookup prefers static children and falls back to the param child
+func (n *apiRadixNode[T]) lookup(path string, params []apiPathParam) (func(*T, http.ResponseWriter, *http.Request, *ApiConfig), []apiPathParam) {
+	if n.param {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end == 0 {
+			return nil, params
+		}
+
+		params = append(params, apiPathParam{Value: path[:end]})
+		path = path[end:]
+	} else {
+		if !strings.HasPrefix(path, n.prefix) {
+			return nil, params
+		}
+		path = path[len(n.prefix):]
+	}
+
+	if path == "" {
+		if n.handler != nil {
+			named := params[len(params)-len(n.names):]
+			for i := range named {
+				named[i].Name = n.names[i]
+			}
+		}
+		return n.handler, params
+	}
+
+	if idx := strings.IndexByte(n.indices, path[0]); idx >= 0 {
+		if handler, found := n.children[idx].lookup(path, params); handler != nil {
+			return handler, found
+		}
+	}
+
+	if n.wildcard != nil {
+		return n.wildcard.lookup(path, params)
+	}
+
+	return nil, params
+}
+`
+)
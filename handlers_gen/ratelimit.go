@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	rateLimitKeyIP        = "ip"
+	rateLimitKeyPrincipal = "principal"
+	rateLimitKeyHeader    = "header:"
+)
+
+// apiGenRateLimit is the "ratelimit" annotation, rate is requests per second
+type apiGenRateLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+	Key   string  `json:"key"`
+}
+
+// checkRateLimits validates "ratelimit" annotations, burst defaults to the
+// rate rounded up and key to "ip"
+func checkRateLimits(funcs []GeneratedFunc) error {
+	for i, f := range funcs {
+		limit := f.RateLimit
+		if limit == nil {
+			continue
+		}
+
+		if limit.Rate <= 0 || math.IsInf(limit.Rate, 0) {
+			return fmt.Errorf("%s.%s: ratelimit rate must be positive, got %v", f.ReceiverTypeName, f.FuncName, limit.Rate)
+		}
+		if limit.Burst < 0 {
+			return fmt.Errorf("%s.%s: ratelimit burst must not be negative, got %d", f.ReceiverTypeName, f.FuncName, limit.Burst)
+		}
+		if limit.Burst == 0 {
+			funcs[i].RateLimit.Burst = int(math.Ceil(limit.Rate))
+		}
+
+		header, isHeader := strings.CutPrefix(limit.Key, rateLimitKeyHeader)
+		switch {
+		case limit.Key == "":
+			funcs[i].RateLimit.Key = rateLimitKeyIP
+		case limit.Key == rateLimitKeyPrincipal && !f.Auth:
+			return fmt.Errorf("%s.%s: ratelimit key %q needs \"auth\": true", f.ReceiverTypeName, f.FuncName, limit.Key)
+		case isHeader && header == "":
+			return fmt.Errorf("%s.%s: ratelimit key %q needs a header name", f.ReceiverTypeName, f.FuncName, limit.Key)
+		case limit.Key != rateLimitKeyIP && limit.Key != rateLimitKeyPrincipal && !isHeader:
+			return fmt.Errorf("%s.%s: unknown ratelimit key %q, expected ip, principal or header:<name>", f.ReceiverTypeName, f.FuncName, limit.Key)
+		}
+	}
+
+	return nil
+}
+
+type checkRateLimitTempl struct {
+	EndpointVar string
+}
+
+var checkRateLimitTemplate = template.Must(template.New("checkRateLimitTempl").Parse(`
+	// check rate limit
+	if !cfg.allowRequest(ctx, w, r, {{.EndpointVar}}) {
+		return
+	}
+`))
+
+// RateLimitExpr is the ApiRateLimit literal of the endpoint
+func (f GeneratedFunc) RateLimitExpr() string {
+	return fmt.Sprintf("&ApiRateLimit{Rate: %s, Burst: %d, Key: %q}",
+		strconv.FormatFloat(f.RateLimit.Rate, 'g', -1, 64), f.RateLimit.Burst, f.RateLimit.Key)
+}
+
+// rateLimitRuntime keeps a token bucket per key of every limited endpoint,
+// keys live in an LRU of ApiConfig.RateLimitKeys entries
+var rateLimitRuntime = `
+// ApiRateLimit allows Rate requests per second with bursts of Burst requests
+// per key: "ip", "principal" or "header:<name>"
+type ApiRateLimit struct {
+	Rate  float64
+	Burst int
+	Key   string
+}
+
+// DefaultRateLimitKeys bounds the keys remembered per endpoint
+const DefaultRateLimitKeys = 10000
+
+type apiBucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// apiRateLimiter forgets the least recently seen key when full, so an
+// evicted key starts again with a full bucket
+type apiRateLimiter struct {
+	limit   *ApiRateLimit
+	maxKeys int
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	lru     *list.List
+}
+
+func newApiRateLimiter(limit *ApiRateLimit, maxKeys int) *apiRateLimiter {
+	if maxKeys <= 0 {
+		maxKeys = DefaultRateLimitKeys
+	}
+	return &apiRateLimiter{
+		limit:   limit,
+		maxKeys: maxKeys,
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// allow takes a token of key, otherwise returns when the next one is ready
+func (l *apiRateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var bucket *apiBucket
+	if elem, ok := l.buckets[key]; ok {
+		l.lru.MoveToFront(elem)
+		bucket = elem.Value.(*apiBucket)
+		elapsed := now.Sub(bucket.last).Seconds()
+		bucket.tokens = math.Min(float64(l.limit.Burst), bucket.tokens+elapsed*l.limit.Rate)
+		bucket.last = now
+	} else {
+		if l.lru.Len() >= l.maxKeys {
+			oldest := l.lru.Back()
+			l.lru.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*apiBucket).key)
+		}
+		bucket = &apiBucket{key: key, tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = l.lru.PushFront(bucket)
+	}
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.limit.Rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// rateLimitKey identifies the caller, ctx carries the principal of auth,
+// "ip" is the peer address and ignores X-Forwarded-For
+func rateLimitKey(ctx context.Context, r *http.Request, key string) string {
+	switch key {
+	case "ip":
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+		return host
+	case "principal":
+		principal, _ := PrincipalFromContext(ctx)
+		return principal.ID
+	default:
+		return r.Header.Get(strings.TrimPrefix(key, "header:"))
+	}
+}
+
+// allowRequest answers 429 with Retry-After in whole seconds when the caller
+// is out of tokens
+func (cfg *ApiConfig) allowRequest(ctx context.Context, w http.ResponseWriter, r *http.Request, endpoint *ApiEndpoint) bool {
+	limiter, ok := cfg.rateLimiters.Load(endpoint)
+	if !ok {
+		limiter, _ = cfg.rateLimiters.LoadOrStore(endpoint, newApiRateLimiter(endpoint.RateLimit, cfg.RateLimitKeys))
+	}
+
+	allowed, retryAfter := limiter.(*apiRateLimiter).allow(rateLimitKey(ctx, r, endpoint.RateLimit.Key), time.Now())
+	if allowed {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	writeError(w, r, http.StatusTooManyRequests, "too many requests")
+	return false
+}
+`
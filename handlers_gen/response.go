@@ -0,0 +1,80 @@
+package main
+
+var responseRuntime = `
+type response struct {
+	XMLName  xml.Name ` + "`json:\"-\" xml:\"response\"`" + `
+	Response any      ` + "`json:\"response,omitempty\" xml:\"response,omitempty\"`" + `
+	Error    string   ` + "`json:\"error\" xml:\"error\"`" + `
+}
+
+var jsonContentType = []string{jsonMediaType}
+
+type responseBuffer struct {
+	bytes.Buffer
+	encoder *json.Encoder
+}
+
+// MaxPooledResponseSize is the largest response buffer kept for reuse, it
+// fits lists of ten thousand users, larger buffers are left to the GC
+var MaxPooledResponseSize = 1 << 20
+
+var responseBufferPool = sync.Pool{
+	New: func() any {
+		buf := &responseBuffer{}
+		buf.encoder = json.NewEncoder(&buf.Buffer)
+		return buf
+	},
+}
+
+func putResponseBuffer(buf *responseBuffer) {
+	if buf.Cap() <= MaxPooledResponseSize {
+		buf.Reset()
+		responseBufferPool.Put(buf)
+	}
+}
+
+// writeResponse encodes body in a single pass into a pooled buffer, so encoding
+// errors can still be answered with 500 before anything is written. The format
+// follows Content-Type set by negotiate, JSON bodies with generated encoders
+// skip encoding/json reflection
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, body any) {
+	buf := responseBufferPool.Get().(*responseBuffer)
+	defer putResponseBuffer(buf)
+
+	header := w.Header()
+	mediaType := mediaTypeOf(header.Get("Content-Type"))
+
+	var err error
+	switch _, isAppender := body.(jsonAppender); {
+	case !isJSONMediaType(mediaType):
+		encoder := lookupEncoder(mediaType)
+		if encoder == nil {
+			err = fmt.Errorf("no encoder for %s", mediaType)
+			break
+		}
+		err = encoder.Encode(&buf.Buffer, body)
+	case isAppender:
+		var data []byte
+		data, err = appendJSONValue(buf.AvailableBuffer(), body)
+		buf.Write(append(data, '\n'))
+	default:
+		err = buf.encoder.Encode(body)
+	}
+
+	if err != nil {
+		if status == http.StatusInternalServerError {
+			w.WriteHeader(status)
+			return
+		}
+
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if mediaType == "" {
+		header["Content-Type"] = jsonContentType
+	}
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+`
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+type routerMount struct {
+	ReceiverTypeName string
+	Prefix           string
+}
+
+func (m routerMount) ArgName() string {
+	name := []rune(m.ReceiverTypeName)
+	name[0] = unicode.ToLower(name[0])
+
+	return string(name)
+}
+
+// mountFlags collects repeated -mount Receiver=/prefix flags
+type mountFlags []routerMount
+
+func (m *mountFlags) String() string {
+	mounts := make([]string, 0, len(*m))
+	for _, mount := range *m {
+		mounts = append(mounts, mount.ReceiverTypeName+"="+mount.Prefix)
+	}
+
+	return strings.Join(mounts, ",")
+}
+
+func (m *mountFlags) Set(value string) error {
+	name, prefix, found := strings.Cut(value, "=")
+	if !found || name == "" {
+		return fmt.Errorf("mount must look like Receiver=/prefix, got %q", value)
+	}
+
+	*m = append(*m, routerMount{
+		ReceiverTypeName: name,
+		Prefix:           strings.TrimSuffix(prefix, "/"),
+	})
+
+	return nil
+}
+
+type routerRoute struct {
+	GeneratedFunc
+	Path string
+}
+
+type routerTempl struct {
+	RouterName string
+	Mounts     []routerMount
+	Routes     []routerRoute
+}
+
+var (
+	routerTemplate = template.Must(template.New("routerTempl").Parse(`
+// ApiRoute describes a single endpoint served by a generated router
+type ApiRoute struct {
+	Receiver string
+	FuncName string
+	Method   string
+	Path     string
+	Auth     bool
+}
+
+// {{.RouterName}} serves the mounted receivers under their prefixes
+type {{.RouterName}} struct {
+	{{- range .Mounts}}
+	{{.ReceiverTypeName}} *{{.ReceiverTypeName}}
+	{{- end}}
+
+	// Config is used by all mounted receivers, DefaultApiConfig when nil
+	Config *ApiConfig
+}
+
+func New{{.RouterName}}({{range $i, $m := .Mounts}}{{if $i}}, {{end}}{{$m.ArgName}} *{{$m.ReceiverTypeName}}{{end}}) *{{.RouterName}} {
+	return &{{.RouterName}}{
+		{{- range .Mounts}}
+		{{.ReceiverTypeName}}: {{.ArgName}},
+		{{- end}}
+		Config: DefaultApiConfig,
+	}
+}
+
+func (rt *{{.RouterName}}) apiConfig() *ApiConfig {
+	if rt.Config == nil {
+		return DefaultApiConfig
+	}
+
+	return rt.Config
+}
+
+var routes{{.RouterName}} = []ApiRoute{
+	{{- range .Routes}}
+	{Receiver: "{{.ReceiverTypeName}}", FuncName: "{{.FuncName}}", Method: "{{.Method}}", Path: "{{.Path}}", Auth: {{.Auth}}},
+	{{- end}}
+}
+
+// Routes returns the combined route table of all mounted receivers
+func (rt *{{.RouterName}}) Routes() []ApiRoute {
+	return append([]ApiRoute(nil), routes{{.RouterName}}...)
+}
+
+`))
+
+	routerSwitchTemplate = template.Must(template.New("routerSwitchTempl").Parse(`
+func (rt *{{.RouterName}}) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+		{{range .Routes}}
+		case "{{.Path}}":
+			rt.{{.ReceiverTypeName}}.handler{{.FuncName}}(w, r, rt.apiConfig())
+		{{end}}
+		default:
+			writeError(w, r, http.StatusNotFound, "unknown method")
+	}
+}
+
+`))
+)
+
+// buildRouterRoutes joins mount prefixes with receiver urls and reports
+// routes which would be served by more than one handler, only mux routing
+// tells methods apart
+func buildRouterRoutes(mounts []routerMount, receivers []string, funcsMap map[string][]GeneratedFunc, routing string) ([]routerMount, []routerRoute, error) {
+	if len(mounts) == 0 {
+		for _, receiver := range receivers {
+			mounts = append(mounts, routerMount{ReceiverTypeName: receiver})
+		}
+	}
+
+	routes := make([]routerRoute, 0)
+	seen := make(map[string]routerRoute)
+
+	for _, mount := range mounts {
+		funcs, ok := funcsMap[mount.ReceiverTypeName]
+		if !ok {
+			return nil, nil, fmt.Errorf("receiver %s has no apigen methods", mount.ReceiverTypeName)
+		}
+
+		for _, f := range funcs {
+			route := routerRoute{
+				GeneratedFunc: f,
+				Path:          mount.Prefix + f.Url,
+			}
+
+			key := routeKey(route.Path)
+			if routing == routingMux {
+				key = muxPattern(f, key)
+			}
+			if prev, exist := seen[key]; exist {
+				return nil, nil, fmt.Errorf("route conflict: %s is served by both %s.%s and %s.%s",
+					route.Path, prev.ReceiverTypeName, prev.FuncName, f.ReceiverTypeName, f.FuncName)
+			}
+
+			seen[key] = route
+			routes = append(routes, route)
+		}
+	}
+
+	return mounts, routes, nil
+}
+
+func generateRouter(out io.Writer, routerName string, mounts []routerMount, receivers []string, funcsMap map[string][]GeneratedFunc, routing string) error {
+	mounts, routes, err := buildRouterRoutes(mounts, receivers, funcsMap, routing)
+	if err != nil {
+		return err
+	}
+
+	templ := routerTempl{
+		RouterName: routerName,
+		Mounts:     mounts,
+		Routes:     routes,
+	}
+
+	err = routerTemplate.Execute(out, templ)
+	if err != nil {
+		return err
+	}
+
+	switch routing {
+	case routingRadix:
+		return radixServeHTTPTemplate.Execute(out, routerRadixTempl(routerName, routes))
+	case routingMux:
+		return muxRegisterTemplate.Execute(out, routerMuxTempl(routerName, routes))
+	}
+
+	return routerSwitchTemplate.Execute(out, templ)
+}
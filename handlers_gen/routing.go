@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	routingSwitch = "switch"
+	routingRadix  = "radix"
+	routingMux    = "mux"
+)
+
+var (
+	pathParamReg    = regexp.MustCompile(`\{([^{}/]+)\}`)
+	paramSegmentReg = regexp.MustCompile(`^\{[^{}/]+\}$`)
+)
+
+// getPathParams returns names of {param} segments of the url pattern
+func getPathParams(url string) []string {
+	params := make([]string, 0)
+	for _, match := range pathParamReg.FindAllStringSubmatch(url, -1) {
+		params = append(params, match[1])
+	}
+
+	return params
+}
+
+// routeKey drops param names so /user/{id} and /user/{login} collide
+func routeKey(url string) string {
+	return pathParamReg.ReplaceAllString(url, "{}")
+}
+
+func checkRouting(routing string, funcs []GeneratedFunc) error {
+	for _, f := range funcs {
+		if strings.ContainsAny(pathParamReg.ReplaceAllString(f.Url, ""), "{}") {
+			return fmt.Errorf("%s.%s: unbalanced or empty {param} in %s", f.ReceiverTypeName, f.FuncName, f.Url)
+		}
+	}
+
+	switch routing {
+	case routingSwitch:
+		for _, f := range funcs {
+			if len(getPathParams(f.Url)) > 0 {
+				return fmt.Errorf("%s.%s: path params in %s need -routing %s or %s", f.ReceiverTypeName, f.FuncName, f.Url, routingRadix, routingMux)
+			}
+		}
+	case routingRadix, routingMux:
+		// a param matches the segment up to the next slash, so /file{name}.txt
+		// could never be served
+		for _, f := range funcs {
+			for _, segment := range strings.Split(f.Url, "/") {
+				if strings.Contains(segment, "{") && !paramSegmentReg.MatchString(segment) {
+					return fmt.Errorf("%s.%s: params must be whole path segments, got %s", f.ReceiverTypeName, f.FuncName, f.Url)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("unknown routing %q", routing)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// statusConstants are written by name in the generated code
+var statusConstants = map[int]string{
+	http.StatusOK:        "http.StatusOK",
+	http.StatusCreated:   "http.StatusCreated",
+	http.StatusAccepted:  "http.StatusAccepted",
+	http.StatusNoContent: "http.StatusNoContent",
+}
+
+// StatusExpr is the success status of the handler, 200 by default
+func (f GeneratedFunc) StatusExpr() string {
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if name, ok := statusConstants[status]; ok {
+		return name
+	}
+
+	return strconv.Itoa(status)
+}
+
+// checkStatuses allows only success statuses in "status"
+func checkStatuses(funcs []GeneratedFunc) error {
+	for _, f := range funcs {
+		if f.Status == 0 {
+			continue
+		}
+		if f.Status < 200 || f.Status > 299 {
+			return fmt.Errorf("%s.%s: status %d is not a success status", f.ReceiverTypeName, f.FuncName, f.Status)
+		}
+		if f.Stream != "" {
+			return fmt.Errorf("%s.%s: status can't be used with stream", f.ReceiverTypeName, f.FuncName)
+		}
+	}
+
+	return nil
+}
+
+var statusRuntime = `
+// StatusCoder lets a result choose the success status at runtime
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// Headerer lets a result add response headers, e.g. Location of a created user
+type Headerer interface {
+	Headers() http.Header
+}
+
+// writeResult applies StatusCoder and Headerer of the result and writes body,
+// 204 and 304 are sent without it
+func writeResult(w http.ResponseWriter, r *http.Request, status int, result, body any) {
+	if rv := reflect.ValueOf(result); rv.Kind() != reflect.Pointer || !rv.IsNil() {
+		if coder, ok := result.(StatusCoder); ok {
+			if code := coder.StatusCode(); code != 0 {
+				status = code
+			}
+		}
+
+		if headerer, ok := result.(Headerer); ok {
+			header := w.Header()
+			for key, values := range headerer.Headers() {
+				for _, value := range values {
+					header.Add(key, value)
+				}
+			}
+		}
+	}
+
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(status)
+		return
+	}
+
+	writeResponse(w, r, status, body)
+}
+`
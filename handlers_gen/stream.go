@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"text/template"
+)
+
+const (
+	streamNDJSON = "ndjson"
+	streamSSE    = "sse"
+)
+
+// streamSignature returns the item type of streaming methods
+//
+//	func (srv *Api) Feed(ctx context.Context, in Params) (<-chan T, error)
+//	func (srv *Api) Feed(ctx context.Context, in Params, send func(T) error) error
+//
+// and whether the method takes a sink
+func streamSignature(f *ast.FuncDecl) (string, bool) {
+	if params := f.Type.Params.List; len(params) == 3 {
+		sink, ok := params[2].Type.(*ast.FuncType)
+		if ok && sink.Params.NumFields() == 1 && sink.Results.NumFields() == 1 {
+			if result, ok := sink.Results.List[0].Type.(*ast.Ident); ok && result.Name == "error" {
+				return types.ExprString(sink.Params.List[0].Type), true
+			}
+		}
+	}
+
+	if f.Type.Results.NumFields() == 2 {
+		if ch, ok := f.Type.Results.List[0].Type.(*ast.ChanType); ok && ch.Dir != ast.SEND {
+			return types.ExprString(ch.Value), false
+		}
+	}
+
+	return "", false
+}
+
+// streamItemExpr is the item type expression for resultTypeName
+func streamItemExpr(f *ast.FuncDecl, sink bool) ast.Expr {
+	if sink {
+		return f.Type.Params.List[2].Type.(*ast.FuncType).Params.List[0].Type
+	}
+
+	return f.Type.Results.List[0].Type.(*ast.ChanType).Value
+}
+
+// checkStreams matches "stream" annotations with streaming signatures
+func checkStreams(funcs []GeneratedFunc) error {
+	for _, f := range funcs {
+		switch {
+		case f.Stream != "" && f.Stream != streamNDJSON && f.Stream != streamSSE:
+			return fmt.Errorf("%s.%s: unknown stream %q, expected %s or %s", f.ReceiverTypeName, f.FuncName, f.Stream, streamNDJSON, streamSSE)
+		case f.Stream != "" && f.StreamItemType == "":
+			return fmt.Errorf("%s.%s: stream needs (<-chan T, error) result or func(T) error sink", f.ReceiverTypeName, f.FuncName)
+		case f.Stream == "" && f.StreamItemType != "":
+			return fmt.Errorf("%s.%s: streaming method needs \"stream\": %q or %q", f.ReceiverTypeName, f.FuncName, streamNDJSON, streamSSE)
+		}
+	}
+
+	return nil
+}
+
+type streamTempl struct {
+	FuncName string
+	ItemType string
+	Sink     bool
+	SSE      bool
+}
+
+var streamTemplate = template.Must(template.New("streamTempl").Parse(`
+	stream := newApiStream(w, {{.SSE}})
+	callCtx, callPhase := cfg.startPhase(ctx, "call")
+{{- if .Sink}}
+	if err := h.{{.FuncName}}(callCtx, params, func(item {{.ItemType}}) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return stream.send(item)
+	}); err != nil {
+		callPhase.end(err)
+		if r.Context().Err() == nil {
+			status, message := cfg.errorResponse(r, err)
+			stream.fail(r, status, message)
+		}
+		return
+	}
+	callPhase.end(nil)
+	stream.close()
+{{- else}}
+	items, err := h.{{.FuncName}}(callCtx, params)
+	callPhase.end(err)
+	if err != nil {
+		status, message := cfg.errorResponse(r, err)
+		writeError(w, r, status, message)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if r.Context().Err() == nil {
+				status, message := cfg.errorResponse(r, ctx.Err())
+				stream.fail(r, status, message)
+			}
+			return
+		case item, ok := <-items:
+			if !ok {
+				stream.close()
+				return
+			}
+			if err := stream.send(item); err != nil {
+				return
+			}
+		}
+	}
+{{- end}}
+`))
+
+// streamRuntime writes items of streaming endpoints, the request context is
+// cancelled when the handler returns, so producers must select on ctx.Done()
+var streamRuntime = `
+// apiStream writes items as NDJSON lines or server-sent events and flushes
+// after each one, headers are sent with the first item
+type apiStream struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	sse        bool
+	started    bool
+	buf        []byte
+}
+
+func newApiStream(w http.ResponseWriter, sse bool) *apiStream {
+	return &apiStream{w: w, controller: http.NewResponseController(w), sse: sse}
+}
+
+func (s *apiStream) start() {
+	header := s.w.Header()
+	if s.sse {
+		header.Set("Content-Type", "text/event-stream")
+	} else {
+		header.Set("Content-Type", "application/x-ndjson")
+	}
+	header.Set("Cache-Control", "no-cache")
+	s.w.WriteHeader(http.StatusOK)
+	s.started = true
+}
+
+func (s *apiStream) send(item any) error {
+	if !s.started {
+		s.start()
+	}
+
+	s.buf = s.buf[:0]
+	if s.sse {
+		s.buf = append(s.buf, "data: "...)
+	}
+
+	var err error
+	s.buf, err = appendJSONValue(s.buf, item)
+	if err != nil {
+		return err
+	}
+
+	return s.write()
+}
+
+func (s *apiStream) write() error {
+	if s.sse {
+		s.buf = append(s.buf, '\n', '\n')
+	} else {
+		s.buf = append(s.buf, '\n')
+	}
+
+	if _, err := s.w.Write(s.buf); err != nil {
+		return err
+	}
+	if err := s.controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	return nil
+}
+
+// fail answers with the regular error response before the first item and
+// with a last {"error": ...} line or an "error" event after it
+func (s *apiStream) fail(r *http.Request, status int, message string) {
+	if !s.started {
+		writeError(s.w, r, status, message)
+		return
+	}
+
+	s.buf = s.buf[:0]
+	if s.sse {
+		s.buf = append(s.buf, "event: error\ndata: "...)
+	}
+	s.buf, _ = response{Error: message}.AppendJSON(s.buf)
+	s.write()
+}
+
+// close sends headers of a stream without items
+func (s *apiStream) close() {
+	if !s.started {
+		s.start()
+	}
+}
+`
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// checkTimeouts parses "timeout" annotations like "2s" or "500ms"
+func checkTimeouts(funcs []GeneratedFunc) error {
+	for _, f := range funcs {
+		if f.Timeout == "" {
+			continue
+		}
+
+		timeout, err := time.ParseDuration(f.Timeout)
+		if err != nil {
+			return fmt.Errorf("%s.%s: bad timeout: %w", f.ReceiverTypeName, f.FuncName, err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("%s.%s: timeout must be positive, got %s", f.ReceiverTypeName, f.FuncName, f.Timeout)
+		}
+	}
+
+	return nil
+}
+
+// TimeoutExpr is the "timeout" annotation as a Go expression, e.g. 2 * time.Second
+func (f GeneratedFunc) TimeoutExpr() string {
+	timeout, _ := time.ParseDuration(f.Timeout)
+
+	for _, unit := range []struct {
+		Duration time.Duration
+		Name     string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	} {
+		if timeout%unit.Duration == 0 {
+			return strconv.FormatInt(int64(timeout/unit.Duration), 10) + " * " + unit.Name
+		}
+	}
+
+	return strconv.FormatInt(int64(timeout), 10)
+}
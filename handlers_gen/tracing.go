@@ -0,0 +1,178 @@
+package main
+
+// tracingRuntime wraps every generated endpoint into a span with phase spans
+// "auth", "validate", "call" and "encode" when ApiConfig.Tracer is set
+var tracingRuntime = `
+// Tracer creates spans around generated handlers and their phases, the span
+// of the endpoint carries attributes of its apigen:api annotation
+type Tracer interface {
+	// Start returns ctx with the new span, spans started with it are children
+	Start(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, Span)
+}
+
+// Span is finished once by End, err is nil when the phase succeeded
+type Span interface {
+	SetAttributes(attrs ...SpanAttr)
+	End(err error)
+}
+
+type SpanAttr struct {
+	Key   string
+	Value any
+}
+
+// ErrRequestRejected ends phase spans which answered with an error, e.g. the
+// "validate" span of a request with a missing required param
+var ErrRequestRejected = errors.New("request rejected")
+
+// NoopTracer is the default tracer, generated handlers skip it entirely
+type NoopTracer struct{}
+
+func (NoopTracer) Start(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...SpanAttr) {}
+
+func (noopSpan) End(err error) {}
+
+func (cfg *ApiConfig) tracer() Tracer {
+	if _, noop := cfg.Tracer.(NoopTracer); noop {
+		return nil
+	}
+	return cfg.Tracer
+}
+
+// spanAttrs describe the endpoint by its annotation
+func (e *ApiEndpoint) spanAttrs() []SpanAttr {
+	attrs := []SpanAttr{
+		{"apigen.receiver", e.Receiver},
+		{"apigen.method", e.Method},
+		{"http.route", e.Route},
+		{"apigen.auth", e.Auth},
+	}
+	if e.HTTPMethod != "" {
+		attrs = append(attrs, SpanAttr{"http.method", e.HTTPMethod})
+	}
+	if len(e.Roles) > 0 {
+		attrs = append(attrs, SpanAttr{"apigen.roles", e.Roles})
+	}
+	if e.Stream != "" {
+		attrs = append(attrs, SpanAttr{"apigen.stream", e.Stream})
+	}
+	if e.Timeout != "" {
+		attrs = append(attrs, SpanAttr{"apigen.timeout", e.Timeout})
+	}
+	return attrs
+}
+
+// apiPhase ends its span at most once, so the deferred end only finishes
+// phases left by an early return
+type apiPhase struct {
+	span  Span
+	ended bool
+}
+
+func (p *apiPhase) end(err error) {
+	if p.span == nil || p.ended {
+		return
+	}
+	p.ended = true
+	p.span.End(err)
+}
+
+func (cfg *ApiConfig) startPhase(ctx context.Context, name string) (context.Context, apiPhase) {
+	tracer := cfg.tracer()
+	if tracer == nil {
+		return ctx, apiPhase{}
+	}
+
+	ctx, span := tracer.Start(ctx, name)
+	return ctx, apiPhase{span: span}
+}
+`
+
+// recordingTracerRuntime is emitted with -recording-tracer
+var recordingTracerRuntime = `
+// RecordingTracer keeps finished spans in memory, it is meant for tests
+type RecordingTracer struct {
+	mu     sync.Mutex
+	lastID int
+	spans  []RecordedSpan
+}
+
+// RecordedSpan is a finished span, ParentID is 0 for root spans
+type RecordedSpan struct {
+	ID       int
+	ParentID int
+	Name     string
+	Attrs    []SpanAttr
+	Err      error
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Attr returns the value of the last attribute with key
+func (s RecordedSpan) Attr(key string) (any, bool) {
+	for i := len(s.Attrs) - 1; i >= 0; i-- {
+		if s.Attrs[i].Key == key {
+			return s.Attrs[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+type recordingSpanCtxKey struct{}
+
+type recordingSpan struct {
+	tracer *RecordingTracer
+	span   RecordedSpan
+}
+
+func (t *RecordingTracer) Start(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, Span) {
+	t.mu.Lock()
+	t.lastID++
+	id := t.lastID
+	t.mu.Unlock()
+
+	span := &recordingSpan{tracer: t, span: RecordedSpan{
+		ID:    id,
+		Name:  name,
+		Attrs: slices.Clone(attrs),
+		Start: time.Now(),
+	}}
+	if parent, ok := ctx.Value(recordingSpanCtxKey{}).(*recordingSpan); ok {
+		span.span.ParentID = parent.span.ID
+	}
+
+	return context.WithValue(ctx, recordingSpanCtxKey{}, span), span
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...SpanAttr) {
+	s.span.Attrs = append(s.span.Attrs, attrs...)
+}
+
+func (s *recordingSpan) End(err error) {
+	s.span.Err = err
+	s.span.Duration = time.Since(s.span.Start)
+
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, s.span)
+	s.tracer.mu.Unlock()
+}
+
+// Spans returns finished spans in the order they ended
+func (t *RecordingTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.spans)
+}
+
+func (t *RecordingTracer) Reset() {
+	t.mu.Lock()
+	t.spans = nil
+	t.mu.Unlock()
+}
+`
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	router := NewApiRouter(NewMyApi(), NewOtherApi())
+	router.Config = NewApiConfig()
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	createAged := func(login, age, key string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/my"+ApiUserSignup,
+			strings.NewReader("login="+login+"&age="+age+"&status=moderator"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Auth", "100500")
+		req.Header.Set("Idempotency-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+	create := func(login, key string) (*http.Response, string) {
+		return createAged(login, "32", key)
+	}
+
+	// повтор с тем же ключом получает первый ответ, а не 409 "user exists"
+	first, firstBody := create("mr.moderator", "k1")
+	retry, retryBody := create("mr.moderator", "k1")
+	if first.StatusCode != http.StatusOK || retry.StatusCode != http.StatusOK || retryBody != firstBody {
+		t.Errorf("expected replay of %d %s, got %d %s", first.StatusCode, firstBody, retry.StatusCode, retryBody)
+	}
+	if retry.Header.Get("Idempotent-Replayed") != "true" || retry.Header.Get("Content-Type") != first.Header.Get("Content-Type") {
+		t.Errorf("unexpected headers of replay %v", retry.Header)
+	}
+
+	// тот же ключ с другими параметрами - ошибка клиента, а не повтор
+	if resp, _ := createAged("mr.moderator", "33", "k1"); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for other params, got %d", resp.StatusCode)
+	}
+
+	if resp, _ := create("mr.moderator", "k2"); resp.StatusCode != http.StatusConflict {
+		t.Errorf("new key must call the method again, got %d", resp.StatusCode)
+	}
+
+	// ключ занят запросом, который еще выполняется
+	router.Config.Idempotency.Begin(context.Background(), "MyApi.Signup\x00100500\x00k3")
+	if resp, _ := create("other.moderator", "k3"); resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected 409 while in flight, got %d", resp.StatusCode)
+	}
+
+	// 500 не запоминается, клиент может повторить запрос
+	if resp, _ := create("bad_username", "k4"); resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", resp.StatusCode)
+	}
+	if resp, _ := create("bad_username", "k4"); resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Error("server errors must not be replayed")
+	}
+
+	// nil в конфиге отключает повторы, второй запрос снова вызывает метод
+	router.Config.Idempotency = nil
+	if resp, _ := create("mr.moderator", "k1"); resp.StatusCode != http.StatusConflict || resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Errorf("expected 409 without a store, got %d", resp.StatusCode)
+	}
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryIdempotencyStore(10 * time.Millisecond)
+
+	if resp, err := store.Begin(ctx, "key"); resp != nil || err != nil {
+		t.Fatalf("expected reservation, got %v %v", resp, err)
+	}
+	if _, err := store.Begin(ctx, "key"); err != ErrIdempotencyInFlight {
+		t.Fatalf("expected ErrIdempotencyInFlight, got %v", err)
+	}
+
+	store.Save(ctx, "key", &IdempotentResponse{Status: http.StatusCreated})
+	if resp, err := store.Begin(ctx, "key"); err != nil || resp == nil || resp.Status != http.StatusCreated {
+		t.Fatalf("expected saved response, got %v %v", resp, err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if resp, err := store.Begin(ctx, "key"); resp != nil || err != nil {
+		t.Errorf("expired response must be forgotten, got %v %v", resp, err)
+	}
+
+	store.Release(ctx, "key")
+	if resp, err := store.Begin(ctx, "key"); resp != nil || err != nil {
+		t.Errorf("released key must be reserved again, got %v %v", resp, err)
+	}
+}
+
+func TestMemoryIdempotencyStoreLimit(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryIdempotencyStore(time.Hour)
+	store.MaxKeys = 2
+
+	// k1 используется последним, поэтому вытесняется k2
+	store.Begin(ctx, "k1")
+	store.Save(ctx, "k1", &IdempotentResponse{Status: http.StatusOK})
+	store.Begin(ctx, "k2")
+	store.Begin(ctx, "k1")
+	store.Begin(ctx, "k3")
+
+	if resp, err := store.Begin(ctx, "k1"); err != nil || resp == nil {
+		t.Errorf("recently used key must be kept, got %v %v", resp, err)
+	}
+	if resp, err := store.Begin(ctx, "k2"); resp != nil || err != nil {
+		t.Errorf("least recently used key must be evicted, got %v %v", resp, err)
+	}
+}
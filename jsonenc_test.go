@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// plain types have the same fields without the generated methods,
+// so encoding/json falls back to reflection for them
+type (
+	plainUser      User
+	plainNewUser   NewUser
+	plainOtherUser OtherUser
+)
+
+var jsonTestStrings = []string{
+	"", "vasily", "Василий Романов", "quote \" and \\ backslash", "<script>&amp;</script>",
+	"\b\f\n\r\t\x00\x1f\x7f", "line\u2028sep\u2029", "bad \xff\xfe utf8", "emoji 😀",
+}
+
+func TestAppendJSONMatchesEncodingJSON(t *testing.T) {
+	for i, s := range jsonTestStrings {
+		values := []struct{ generated, plain any }{
+			{User{ID: uint64(i), Login: s, FullName: s, Status: -i}, plainUser{ID: uint64(i), Login: s, FullName: s, Status: -i}},
+			{NewUser{ID: math.MaxUint64 - uint64(i)}, plainNewUser{ID: math.MaxUint64 - uint64(i)}},
+			{OtherUser{Login: s, Level: i}, plainOtherUser{Login: s, Level: i}},
+			{response{Response: &User{Login: s}, Error: s}, struct {
+				Response *plainUser `json:"response,omitempty"`
+				Error    string     `json:"error"`
+			}{&plainUser{Login: s}, s}},
+		}
+
+		for _, value := range values {
+			got, err := value.generated.(jsonAppender).AppendJSON([]byte("prefix"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, _ := json.Marshal(value.plain)
+			if string(got) != "prefix"+string(expected) {
+				t.Errorf("%T: got %s, encoding/json gives %s", value.generated, got, expected)
+			}
+		}
+	}
+
+	users := benchmarkUsers(2)
+	for _, value := range []any{
+		(*User)(nil), response{}, response{Response: (*User)(nil)}, response{Response: []int{1}},
+		// срезы, массивы и указатели на типы с AppendJSON обходятся без encoding/json
+		[]*User{users[0], nil}, []User{*users[1]}, [2]User{}, &users, []*User(nil), []User{}, [][]*User{users},
+		response{Response: users}, []plainUser{plainUser(*users[0])},
+	} {
+		got, err := appendJSONValue(nil, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := json.Marshal(value)
+		if string(got) != string(expected) {
+			t.Errorf("%#v: got %s, encoding/json gives %s", value, got, expected)
+		}
+	}
+
+	if !jsonAppendable(reflect.TypeFor[[]*User]()) || jsonAppendable(reflect.TypeFor[[]plainUser]()) {
+		t.Error("unexpected jsonAppendable result")
+	}
+}
+
+func TestAppendJSONFloat(t *testing.T) {
+	for _, f := range []float64{0, 1, -1.5, 0.1, 1e-7, 1.23e-10, 1e20, 1e21, 1e100, math.MaxFloat64, math.SmallestNonzeroFloat64} {
+		got, _ := appendJSONFloat(nil, f, 64)
+		expected, _ := json.Marshal(f)
+		if string(got) != string(expected) {
+			t.Errorf("float64 %v: got %s, encoding/json gives %s", f, got, expected)
+		}
+
+		got, _ = appendJSONFloat(nil, float64(float32(f)), 32)
+		expected, _ = json.Marshal(float32(f))
+		if string(got) != string(expected) {
+			t.Errorf("float32 %v: got %s, encoding/json gives %s", float32(f), got, expected)
+		}
+	}
+
+	if _, err := appendJSONFloat(nil, math.NaN(), 64); err == nil {
+		t.Error("expected error for NaN")
+	}
+}
+
+func BenchmarkAppendJSON(b *testing.B) {
+	user := User{ID: 42, Login: "rvasily", FullName: strings.Repeat("Vasily Romanov ", 4), Status: statusAdmin}
+	plain := plainUser(user)
+
+	b.Run("encoding-json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			json.Marshal(plain)
+		}
+	})
+
+	b.Run("append-json", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 256)
+		for i := 0; i < b.N; i++ {
+			buf, _ = user.AppendJSON(buf[:0])
+		}
+	})
+
+	b.Run("envelope-encoding-json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			json.Marshal(response{Response: &plain})
+		}
+	})
+
+	b.Run("envelope-append-json", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 256)
+		for i := 0; i < b.N; i++ {
+			buf, _ = response{Response: &user}.AppendJSON(buf[:0])
+		}
+	})
+
+	users := benchmarkUsers(100)
+	plainUsers := make([]*plainUser, len(users))
+	for i, u := range users {
+		plainUsers[i] = (*plainUser)(u)
+	}
+
+	b.Run("slice-encoding-json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			json.Marshal(response{Response: plainUsers})
+		}
+	})
+
+	b.Run("slice-append-json", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 16<<10)
+		for i := 0; i < b.N; i++ {
+			buf, _ = response{Response: users}.AppendJSON(buf[:0])
+		}
+	})
+}
@@ -9,6 +9,17 @@ func main() {
 	// будет вызван метод ServeHTTP у структуры MyApi
 	http.Handle("/user/", NewMyApi())
 
+	// сгенерированный роутер сам раскладывает запросы по MyApi и OtherApi
+	router := NewApiRouter(NewMyApi(), NewOtherApi())
+	http.Handle("/my/", router)
+	http.Handle("/other/", router)
+
+	// метрики ручек роутера в формате Prometheus
+	metrics := NewPrometheusMetrics()
+	router.Config = NewApiConfig()
+	router.Config.Metrics = metrics
+	http.Handle("/metrics", metrics)
+
 	fmt.Println("starting server at :8080")
 	http.ListenAndServe(":8080", nil)
 }
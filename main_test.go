@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -342,3 +343,113 @@ func runTests(t *testing.T, ts *httptest.Server, cases []Case) {
 		}
 	}
 }
+
+func TestApiRouter(t *testing.T) {
+	ts := httptest.NewServer(NewApiRouter(NewMyApi(), NewOtherApi()))
+
+	cases := []Case{
+		Case{
+			Path:   "/my" + ApiUserProfile,
+			Query:  "login=rvasily",
+			Status: http.StatusOK,
+			Result: CR{
+				"error": "",
+				"response": CR{
+					"id":        42,
+					"login":     "rvasily",
+					"full_name": "Vasily Romanov",
+					"status":    20,
+				},
+			},
+		},
+		Case{
+			Path:   "/other" + ApiUserCreate,
+			Method: http.MethodPost,
+			Query:  "username=I3apBap&level=1&class=warrior&account_name=Vasily",
+			Status: http.StatusOK,
+			Auth:   true,
+			Result: CR{
+				"error": "",
+				"response": CR{
+					"id":        12,
+					"login":     "I3apBap",
+					"full_name": "Vasily",
+					"level":     1,
+				},
+			},
+		},
+		Case{
+			Path:   ApiUserCreate,
+			Method: http.MethodPost,
+			Status: http.StatusNotFound,
+			Auth:   true,
+			Result: CR{
+				"error": "unknown method",
+			},
+		},
+		Case{ // ApiError отдаёт свой статус
+			Path:   "/my" + ApiUserProfile,
+			Query:  "login=not_exist_user",
+			Status: http.StatusNotFound,
+			Result: CR{
+				"error": "user not exist",
+			},
+		},
+		Case{ // прочие ошибки не уходят клиенту
+			Path:   "/my" + ApiUserProfile,
+			Query:  "login=bad_user",
+			Status: http.StatusInternalServerError,
+			Result: CR{
+				"error": "internal error",
+			},
+		},
+	}
+
+	runTests(t, ts, cases)
+
+	routes := NewApiRouter(nil, nil).Routes()
+	if len(routes) != 5 || routes[2].Path != "/my"+ApiUserSignup || routes[4].Path != "/other"+ApiUserCreate || routes[4].Receiver != "OtherApi" {
+		t.Errorf("unexpected route table: %#v", routes)
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	router := NewApiRouter(NewMyApi(), NewOtherApi())
+	router.Config = &ApiConfig{
+		Authenticator: AuthenticatorFunc(func(r *http.Request) (context.Context, error) {
+			if r.Header.Get("X-Auth") != "secret" {
+				return nil, errUnauthorized
+			}
+			return ContextWithPrincipal(r.Context(), Principal{ID: "admin", Roles: []string{"admin"}}), nil
+		}),
+	}
+	ts := httptest.NewServer(router)
+
+	cases := []Case{
+		Case{ // стандартный токен больше не подходит
+			Path:   "/other" + ApiUserCreate,
+			Method: http.MethodPost,
+			Query:  "username=I3apBap&level=1&class=warrior&account_name=Vasily",
+			Status: http.StatusForbidden,
+			Auth:   true,
+			Result: CR{
+				"error": "unauthorized",
+			},
+		},
+	}
+
+	runTests(t, ts, cases)
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-Auth", "secret")
+	ctx, err := router.Config.Authenticator.Authenticate(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal, ok := PrincipalFromContext(ctx); !ok || principal.ID != "admin" {
+		t.Errorf("unexpected principal %#v", principal)
+	}
+	if !hasAnyRole(ctx, "moderator", "admin") || hasAnyRole(ctx, "user") || hasAnyRole(context.Background(), "admin") {
+		t.Error("unexpected role check result")
+	}
+}
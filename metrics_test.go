@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	metrics := NewPrometheusMetrics()
+	router := NewApiRouter(NewMyApi(), NewOtherApi())
+	router.Config = NewApiConfig()
+	router.Config.ErrorLogger = nil
+	router.Config.Metrics = metrics
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	for _, query := range []string{"login=rvasily", "login=rvasily", "login=not_exist_user", "login=bad_user"} {
+		resp, err := http.Get(ts.URL + "/my" + ApiUserProfile + "?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	w := httptest.NewRecorder()
+	metrics.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(w.Body)
+
+	for _, expected := range []string{
+		`apigen_requests_total{receiver="MyApi",method="Profile",status="200"} 2`,
+		`apigen_requests_total{receiver="MyApi",method="Profile",status="404"} 1`,
+		`apigen_requests_total{receiver="MyApi",method="Profile",status="500"} 1`,
+		`apigen_request_duration_seconds_bucket{receiver="MyApi",method="Profile",le="+Inf"} 4`,
+		`apigen_request_duration_seconds_count{receiver="MyApi",method="Profile"} 4`,
+		`apigen_requests_in_flight{receiver="MyApi",method="Profile"} 0`,
+		"# TYPE apigen_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("%s not found in:\n%s", expected, body)
+		}
+	}
+
+	metrics.Start("OtherApi", "Create")
+	metrics.Done("OtherApi", "Create", http.StatusOK, 30*time.Millisecond)
+	metrics.Start("OtherApi", "Create")
+
+	w = httptest.NewRecorder()
+	metrics.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, expected := range []string{
+		`apigen_request_duration_seconds_bucket{receiver="OtherApi",method="Create",le="0.025"} 0`,
+		`apigen_request_duration_seconds_bucket{receiver="OtherApi",method="Create",le="0.05"} 1`,
+		`apigen_requests_in_flight{receiver="OtherApi",method="Create"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("%s not found in:\n%s", expected, w.Body)
+		}
+	}
+}
+
+func TestStatusWriterFlush(t *testing.T) {
+	cfg := NewApiConfig()
+	cfg.Metrics = NewPrometheusMetrics()
+
+	rec := httptest.NewRecorder()
+	w, _, done := cfg.observe(rec, httptest.NewRequest(http.MethodGet, "/feed", nil), &ApiEndpoint{Receiver: "MyApi", Method: "Feed"})
+	newApiStream(w, false).send(benchmarkUsers(1)[0])
+	done()
+
+	if !rec.Flushed || w.(*apiStatusWriter).Status() != http.StatusOK {
+		t.Error("stream is not flushed through the status writer")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testMiddleware(name string, calls *[]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	var calls []string
+	cfg := NewApiConfig()
+	cfg.Middlewares["audit"] = testMiddleware("audit", &calls)
+	cfg.Middlewares["ratelimit"] = testMiddleware("ratelimit", &calls)
+
+	handler := cfg.wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	}, "audit", "ratelimit")
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if strings.Join(calls, ",") != "audit,ratelimit,handler" {
+		t.Errorf("unexpected call order %v", calls)
+	}
+
+	var logged []error
+	cfg.ErrorLogger = func(r *http.Request, err error) {
+		logged = append(logged, err)
+	}
+	delete(cfg.Middlewares, "ratelimit")
+
+	w := httptest.NewRecorder()
+	cfg.wrap(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler must not be called without registered middleware")
+	}, "audit", "ratelimit").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError || len(logged) != 1 || !strings.Contains(logged[0].Error(), `"ratelimit"`) {
+		t.Errorf("unexpected response %d and logged %v", w.Code, logged)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	offered := []string{jsonMediaType, xmlMediaType, "text/xml"}
+	cases := []struct {
+		Accept   string
+		Expected string
+	}{
+		{"*/*", jsonMediaType},
+		{"application/xml", xmlMediaType},
+		{"text/xml, application/json;q=0.5", "text/xml"},
+		{"application/*;q=0.2, application/xml", xmlMediaType},
+		{"application/json;q=0, */*;q=0.1", xmlMediaType},
+		{"text/*", "text/xml"},
+		{"Application/XML; charset=utf-8", xmlMediaType},
+		{"image/png", ""},
+		{"application/json;q=0", ""},
+	}
+
+	for _, item := range cases {
+		if mediaType := negotiateMediaType(item.Accept, offered); mediaType != item.Expected {
+			t.Errorf("[%s] expected %q, got %q", item.Accept, item.Expected, mediaType)
+		}
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	// реестр кодеков глобальный, text/plain не должен достаться другим тестам
+	encodersMu.Lock()
+	savedEncoders, savedMediaTypes := maps.Clone(encoders), slices.Clone(encoderMediaTypes)
+	encodersMu.Unlock()
+	t.Cleanup(func() {
+		encodersMu.Lock()
+		encoders, encoderMediaTypes = savedEncoders, savedMediaTypes
+		encodersMu.Unlock()
+	})
+
+	RegisterEncoder("text/plain", EncoderFunc(func(w io.Writer, v any) error {
+		resp := v.(response)
+		_, err := fmt.Fprintf(w, "%v|%s", resp.Response, resp.Error)
+		return err
+	}))
+
+	ts := httptest.NewServer(NewApiRouter(NewMyApi(), NewOtherApi()))
+	defer ts.Close()
+
+	cases := []struct {
+		Query       string
+		Accept      string
+		Status      int
+		ContentType string
+		Body        string
+	}{
+		{
+			Query:       "login=rvasily",
+			Accept:      "application/xml",
+			Status:      http.StatusOK,
+			ContentType: xmlMediaType,
+			Body:        `<?xml version="1.0" encoding="UTF-8"?>` + "\n<response><response><ID>42</ID><Login>rvasily</Login><FullName>Vasily Romanov</FullName><Status>20</Status></response><error></error></response>",
+		},
+		{ // ошибки тоже в выбранном формате
+			Query:       "login=not_exist_user",
+			Accept:      "text/xml;q=0.9, application/json;q=0.1",
+			Status:      http.StatusNotFound,
+			ContentType: "text/xml",
+			Body:        `<?xml version="1.0" encoding="UTF-8"?>` + "\n<response><error>user not exist</error></response>",
+		},
+		{
+			Query:       "login=rvasily",
+			Accept:      "text/plain",
+			Status:      http.StatusOK,
+			ContentType: "text/plain",
+			Body:        "&{42 rvasily Vasily Romanov 20}|",
+		},
+		{
+			Query:       "login=rvasily",
+			Accept:      "image/png",
+			Status:      http.StatusNotAcceptable,
+			ContentType: jsonMediaType,
+			Body:        `{"error":"not acceptable"}` + "\n",
+		},
+	}
+
+	// без авторизации 403 раньше, чем 406 за неподходящий Accept,
+	// а сама ошибка уже в выбранном формате
+	for accept, contentType := range map[string]string{"image/png": jsonMediaType, "application/xml": xmlMediaType} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/my"+ApiUserCreate, nil)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden || resp.Header.Get("Content-Type") != contentType {
+			t.Errorf("[%s] expected 403 %s, got %d %s", accept, contentType, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+	}
+
+	for _, item := range cases {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/my"+ApiUserProfile+"?"+item.Query, nil)
+		req.Header.Set("Accept", item.Accept)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != item.Status || resp.Header.Get("Content-Type") != item.ContentType || string(body) != item.Body {
+			t.Errorf("[%s] expected %d %s %s, got %d %s %s", item.Accept, item.Status, item.ContentType, item.Body,
+				resp.StatusCode, resp.Header.Get("Content-Type"), body)
+		}
+		if resp.Header.Get("Vary") != "Accept" {
+			t.Errorf("[%s] expected Vary: Accept", item.Accept)
+		}
+	}
+}
+
+func TestErrorXML(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", xmlMediaType)
+	r := httptest.NewRequest(http.MethodGet, ApiUserProfile, nil)
+
+	// application/problem+xml of -errors problem goes to the XML encoder
+	if lookupEncoder("application/problem+xml") == nil || lookupEncoder("application/x-unknown") != nil {
+		t.Error("unexpected encoder lookup")
+	}
+
+	writeError(w, r, http.StatusBadRequest, "login must me not empty", "login")
+	if !strings.HasPrefix(w.Body.String(), "<?xml") || w.Code != http.StatusBadRequest {
+		t.Errorf("unexpected error response %d %s", w.Code, w.Body)
+	}
+}
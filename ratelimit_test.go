@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ApiUserSignup - Create с аннотациями ratelimit, max_body, consumes и idempotent
+const ApiUserSignup = "/user/signup"
+
+func TestRateLimit(t *testing.T) {
+	router := NewApiRouter(NewMyApi(), NewOtherApi())
+	router.Config = NewApiConfig()
+
+	// на реальных часах 10 токенов в секунду копятся, пока идут 20 запросов
+	// burst, поэтому лимит из аннотации заменяется одним токеном на 1000 секунд
+	if limit := *apiEndpointMyApiSignup.RateLimit; limit != (ApiRateLimit{Rate: 10, Burst: 20, Key: "ip"}) {
+		t.Fatalf("unexpected rate limit of MyApi.Signup %+v", limit)
+	}
+	router.Config.rateLimiters.Store(apiEndpointMyApiSignup, newApiRateLimiter(&ApiRateLimit{Rate: 0.001, Burst: 1, Key: "ip"}, 0))
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	created := 0
+	create := func() *http.Response {
+		created++
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/my"+ApiUserSignup,
+			strings.NewReader(fmt.Sprintf("login=moderator%d&age=32&status=moderator", created)))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Auth", "100500")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := create(); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	resp := create()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "1000" {
+		t.Errorf("expected 429 with Retry-After: 1000, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	// неавторизованный запрос отбивается раньше лимита и не тратит токены
+	resp, err := http.Post(ts.URL+"/my"+ApiUserSignup, "application/x-www-form-urlencoded", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 before the rate limit, got %d", resp.StatusCode)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newApiRateLimiter(&ApiRateLimit{Rate: 2, Burst: 2}, 2)
+	now := time.Now()
+
+	for i, c := range []struct {
+		Key        string
+		After      time.Duration
+		Allowed    bool
+		RetryAfter time.Duration
+	}{
+		{"a", 0, true, 0},
+		{"a", 0, true, 0},
+		{"a", 0, false, 500 * time.Millisecond},
+		{"a", 250 * time.Millisecond, false, 250 * time.Millisecond},
+		{"a", 250 * time.Millisecond, true, 0},
+		{"b", 0, true, 0},
+		// c вытесняет a, давно не приходивший ключ снова получает полный burst
+		{"c", 0, true, 0},
+		{"a", 0, true, 0},
+		{"a", 0, true, 0},
+	} {
+		now = now.Add(c.After)
+		allowed, retryAfter := limiter.allow(c.Key, now)
+		if allowed != c.Allowed || retryAfter != c.RetryAfter {
+			t.Errorf("[%d] %s: expected %v %v, got %v %v", i, c.Key, c.Allowed, c.RetryAfter, allowed, retryAfter)
+		}
+	}
+
+	if limiter.lru.Len() != 2 || len(limiter.buckets) != 2 {
+		t.Errorf("expected 2 remembered keys, got %d", len(limiter.buckets))
+	}
+}
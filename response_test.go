@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func benchmarkUsers(count int) []*User {
+	users := make([]*User, count)
+	for i := range users {
+		users[i] = &User{
+			ID:       uint64(i),
+			Login:    fmt.Sprintf("user%d", i),
+			FullName: "Vasily Romanov",
+			Status:   statusModerator,
+		}
+	}
+
+	return users
+}
+
+// discardWriter keeps benchmarks focused on encoding, httptest.ResponseRecorder
+// copies headers and body on every response
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+func TestWriteResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, ApiUserProfile, nil)
+
+	writeResponse(w, r, http.StatusCreated, response{Response: benchmarkUsers(1)[0]})
+
+	if w.Code != http.StatusCreated || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected status %d and content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	expected := `{"response":{"id":0,"login":"user0","full_name":"Vasily Romanov","status":10},"error":""}` + "\n"
+	if w.Body.String() != expected {
+		t.Errorf("unexpected body %s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	writeResponse(w, r, http.StatusOK, response{Response: func() {}})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for not encodable response, got %d", w.Code)
+	}
+}
+
+func BenchmarkResponseEncoding(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, ApiUserProfile, nil)
+
+	for _, count := range []int{1, 100, 10000} {
+		users := benchmarkUsers(count)
+
+		// the way handlers encoded responses before: result, RawMessage, envelope
+		b.Run(fmt.Sprintf("double-marshal-%d", count), func(b *testing.B) {
+			w := &discardWriter{header: http.Header{}}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				data, _ := json.Marshal(users)
+				data, _ = json.Marshal(struct {
+					Response json.RawMessage `json:"response"`
+					Error    string          `json:"error"`
+				}{Response: data})
+				w.WriteHeader(http.StatusOK)
+				w.Write(data)
+			}
+		})
+
+		b.Run(fmt.Sprintf("write-json-%d", count), func(b *testing.B) {
+			w := &discardWriter{header: http.Header{}}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				clear(w.header)
+				writeResponse(w, r, http.StatusOK, response{Response: users})
+			}
+		})
+
+		// с лимитом 64KB большие буферы не возвращаются в пул
+		b.Run(fmt.Sprintf("write-json-pool-64KB-%d", count), func(b *testing.B) {
+			defer func(size int) { MaxPooledResponseSize = size }(MaxPooledResponseSize)
+			MaxPooledResponseSize = 64 << 10
+
+			w := &discardWriter{header: http.Header{}}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				clear(w.header)
+				writeResponse(w, r, http.StatusOK, response{Response: users})
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type radixDummy struct{}
+
+func radixDummyHandler(name string) func(*radixDummy, http.ResponseWriter, *http.Request, *ApiConfig) {
+	return func(_ *radixDummy, w http.ResponseWriter, _ *http.Request, _ *ApiConfig) {
+		w.Write([]byte(name))
+	}
+}
+
+func radixHandlerName(handler func(*radixDummy, http.ResponseWriter, *http.Request, *ApiConfig)) string {
+	if handler == nil {
+		return ""
+	}
+
+	rec := httptest.NewRecorder()
+	handler(nil, rec, nil, nil)
+
+	return rec.Body.String()
+}
+
+func TestRadixLookup(t *testing.T) {
+	tree := newApiRadixTree(
+		apiRadixRoute[radixDummy]{"/user/profile", radixDummyHandler("profile")},
+		apiRadixRoute[radixDummy]{"/user/create", radixDummyHandler("create")},
+		apiRadixRoute[radixDummy]{"/user/{login}", radixDummyHandler("user")},
+		apiRadixRoute[radixDummy]{"/user/{login}/posts/{id}", radixDummyHandler("post")},
+		apiRadixRoute[radixDummy]{"/users", radixDummyHandler("users")},
+		apiRadixRoute[radixDummy]{"/user/{id}/friends", radixDummyHandler("friends")},
+	)
+
+	cases := []struct {
+		Path    string
+		Handler string
+		Params  []apiPathParam
+	}{
+		{Path: "/user/profile", Handler: "profile"},
+		{Path: "/user/create", Handler: "create"},
+		{Path: "/users", Handler: "users"},
+		{Path: "/user/rvasily", Handler: "user", Params: []apiPathParam{{"login", "rvasily"}}},
+		{Path: "/user/prof", Handler: "user", Params: []apiPathParam{{"login", "prof"}}},
+		{Path: "/user/profile/posts/7", Handler: "post", Params: []apiPathParam{{"login", "profile"}, {"id", "7"}}},
+		// узел параметра общий, но имя берется из маршрута
+		{Path: "/user/7/friends", Handler: "friends", Params: []apiPathParam{{"id", "7"}}},
+		{Path: "/user/", Handler: ""},
+		{Path: "/user/rvasily/posts", Handler: ""},
+		{Path: "/unknown", Handler: ""},
+	}
+
+	for _, item := range cases {
+		handler, params := tree.lookup(item.Path, nil)
+		if name := radixHandlerName(handler); name != item.Handler {
+			t.Errorf("[%s] expected handler %q, got %q", item.Path, item.Handler, name)
+			continue
+		}
+		if item.Handler != "" && fmt.Sprint(params) != fmt.Sprint(item.Params) {
+			t.Errorf("[%s] expected params %v, got %v", item.Path, item.Params, params)
+		}
+	}
+}
+
+//go:generate go run routing_switch_gen.go
+
+func switchRouteNoop(*radixDummy, http.ResponseWriter, *http.Request, *ApiConfig) {}
+
+func BenchmarkRouting(b *testing.B) {
+	switches := map[int]func(string) func(*radixDummy, http.ResponseWriter, *http.Request, *ApiConfig){
+		10:   switchRoute10,
+		100:  switchRoute100,
+		1000: switchRoute1000,
+	}
+
+	for _, count := range []int{10, 100, 1000} {
+		routes := make([]apiRadixRoute[radixDummy], count)
+		for i := range routes {
+			routes[i] = apiRadixRoute[radixDummy]{fmt.Sprintf("/service%d/user/profile", i), switchRouteNoop}
+		}
+		tree := newApiRadixTree(routes...)
+		target := routes[count-1].Pattern
+
+		// switch r.URL.Path как в -routing switch, сгенерирован go generate
+		route := switches[count]
+		if route(target) == nil {
+			b.Fatalf("switch of %d routes does not match %s", count, target)
+		}
+		b.Run(fmt.Sprintf("switch-%d", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				route(target)
+			}
+		})
+
+		b.Run(fmt.Sprintf("radix-%d", count), func(b *testing.B) {
+			var buf [4]apiPathParam
+			for i := 0; i < b.N; i++ {
+				tree.lookup(target, buf[:0])
+			}
+		})
+
+		paramRoutes := make([]apiRadixRoute[radixDummy], count)
+		for i := range paramRoutes {
+			paramRoutes[i] = apiRadixRoute[radixDummy]{fmt.Sprintf("/service%d/user/{login}", i), switchRouteNoop}
+		}
+		paramTree := newApiRadixTree(paramRoutes...)
+		paramTarget := fmt.Sprintf("/service%d/user/rvasily", count-1)
+
+		b.Run(fmt.Sprintf("radix-params-%d", count), func(b *testing.B) {
+			var buf [4]apiPathParam
+			for i := 0; i < b.N; i++ {
+				paramTree.lookup(paramTarget, buf[:0])
+			}
+		})
+	}
+}
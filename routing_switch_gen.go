@@ -0,0 +1,42 @@
+//go:build ignore
+
+// routing_switch_gen.go writes routing_switch_test.go, the switch routers
+// BenchmarkRouting compares with the radix tree
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+)
+
+func main() {
+	out := &bytes.Buffer{}
+	fmt.Fprintln(out, "// Code generated by routing_switch_gen.go; DO NOT EDIT.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "package main")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `import "net/http"`)
+
+	for _, count := range []int{10, 100, 1000} {
+		fmt.Fprintf(out, "\nfunc switchRoute%d(path string) func(*radixDummy, http.ResponseWriter, *http.Request, *ApiConfig) {\n", count)
+		fmt.Fprintln(out, "	switch path {")
+		for i := 0; i < count; i++ {
+			fmt.Fprintf(out, "	case \"/service%d/user/profile\":\n", i)
+			fmt.Fprintln(out, "		return switchRouteNoop")
+		}
+		fmt.Fprintln(out, "	}")
+		fmt.Fprintln(out, "	return nil")
+		fmt.Fprintln(out, "}")
+	}
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("routing_switch_test.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,2243 @@
+// Code generated by routing_switch_gen.go; DO NOT EDIT.
+
+package main
+
+import "net/http"
+
+func switchRoute10(path string) func(*radixDummy, http.ResponseWriter, *http.Request, *ApiConfig) {
+	switch path {
+	case "/service0/user/profile":
+		return switchRouteNoop
+	case "/service1/user/profile":
+		return switchRouteNoop
+	case "/service2/user/profile":
+		return switchRouteNoop
+	case "/service3/user/profile":
+		return switchRouteNoop
+	case "/service4/user/profile":
+		return switchRouteNoop
+	case "/service5/user/profile":
+		return switchRouteNoop
+	case "/service6/user/profile":
+		return switchRouteNoop
+	case "/service7/user/profile":
+		return switchRouteNoop
+	case "/service8/user/profile":
+		return switchRouteNoop
+	case "/service9/user/profile":
+		return switchRouteNoop
+	}
+	return nil
+}
+
+func switchRoute100(path string) func(*radixDummy, http.ResponseWriter, *http.Request, *ApiConfig) {
+	switch path {
+	case "/service0/user/profile":
+		return switchRouteNoop
+	case "/service1/user/profile":
+		return switchRouteNoop
+	case "/service2/user/profile":
+		return switchRouteNoop
+	case "/service3/user/profile":
+		return switchRouteNoop
+	case "/service4/user/profile":
+		return switchRouteNoop
+	case "/service5/user/profile":
+		return switchRouteNoop
+	case "/service6/user/profile":
+		return switchRouteNoop
+	case "/service7/user/profile":
+		return switchRouteNoop
+	case "/service8/user/profile":
+		return switchRouteNoop
+	case "/service9/user/profile":
+		return switchRouteNoop
+	case "/service10/user/profile":
+		return switchRouteNoop
+	case "/service11/user/profile":
+		return switchRouteNoop
+	case "/service12/user/profile":
+		return switchRouteNoop
+	case "/service13/user/profile":
+		return switchRouteNoop
+	case "/service14/user/profile":
+		return switchRouteNoop
+	case "/service15/user/profile":
+		return switchRouteNoop
+	case "/service16/user/profile":
+		return switchRouteNoop
+	case "/service17/user/profile":
+		return switchRouteNoop
+	case "/service18/user/profile":
+		return switchRouteNoop
+	case "/service19/user/profile":
+		return switchRouteNoop
+	case "/service20/user/profile":
+		return switchRouteNoop
+	case "/service21/user/profile":
+		return switchRouteNoop
+	case "/service22/user/profile":
+		return switchRouteNoop
+	case "/service23/user/profile":
+		return switchRouteNoop
+	case "/service24/user/profile":
+		return switchRouteNoop
+	case "/service25/user/profile":
+		return switchRouteNoop
+	case "/service26/user/profile":
+		return switchRouteNoop
+	case "/service27/user/profile":
+		return switchRouteNoop
+	case "/service28/user/profile":
+		return switchRouteNoop
+	case "/service29/user/profile":
+		return switchRouteNoop
+	case "/service30/user/profile":
+		return switchRouteNoop
+	case "/service31/user/profile":
+		return switchRouteNoop
+	case "/service32/user/profile":
+		return switchRouteNoop
+	case "/service33/user/profile":
+		return switchRouteNoop
+	case "/service34/user/profile":
+		return switchRouteNoop
+	case "/service35/user/profile":
+		return switchRouteNoop
+	case "/service36/user/profile":
+		return switchRouteNoop
+	case "/service37/user/profile":
+		return switchRouteNoop
+	case "/service38/user/profile":
+		return switchRouteNoop
+	case "/service39/user/profile":
+		return switchRouteNoop
+	case "/service40/user/profile":
+		return switchRouteNoop
+	case "/service41/user/profile":
+		return switchRouteNoop
+	case "/service42/user/profile":
+		return switchRouteNoop
+	case "/service43/user/profile":
+		return switchRouteNoop
+	case "/service44/user/profile":
+		return switchRouteNoop
+	case "/service45/user/profile":
+		return switchRouteNoop
+	case "/service46/user/profile":
+		return switchRouteNoop
+	case "/service47/user/profile":
+		return switchRouteNoop
+	case "/service48/user/profile":
+		return switchRouteNoop
+	case "/service49/user/profile":
+		return switchRouteNoop
+	case "/service50/user/profile":
+		return switchRouteNoop
+	case "/service51/user/profile":
+		return switchRouteNoop
+	case "/service52/user/profile":
+		return switchRouteNoop
+	case "/service53/user/profile":
+		return switchRouteNoop
+	case "/service54/user/profile":
+		return switchRouteNoop
+	case "/service55/user/profile":
+		return switchRouteNoop
+	case "/service56/user/profile":
+		return switchRouteNoop
+	case "/service57/user/profile":
+		return switchRouteNoop
+	case "/service58/user/profile":
+		return switchRouteNoop
+	case "/service59/user/profile":
+		return switchRouteNoop
+	case "/service60/user/profile":
+		return switchRouteNoop
+	case "/service61/user/profile":
+		return switchRouteNoop
+	case "/service62/user/profile":
+		return switchRouteNoop
+	case "/service63/user/profile":
+		return switchRouteNoop
+	case "/service64/user/profile":
+		return switchRouteNoop
+	case "/service65/user/profile":
+		return switchRouteNoop
+	case "/service66/user/profile":
+		return switchRouteNoop
+	case "/service67/user/profile":
+		return switchRouteNoop
+	case "/service68/user/profile":
+		return switchRouteNoop
+	case "/service69/user/profile":
+		return switchRouteNoop
+	case "/service70/user/profile":
+		return switchRouteNoop
+	case "/service71/user/profile":
+		return switchRouteNoop
+	case "/service72/user/profile":
+		return switchRouteNoop
+	case "/service73/user/profile":
+		return switchRouteNoop
+	case "/service74/user/profile":
+		return switchRouteNoop
+	case "/service75/user/profile":
+		return switchRouteNoop
+	case "/service76/user/profile":
+		return switchRouteNoop
+	case "/service77/user/profile":
+		return switchRouteNoop
+	case "/service78/user/profile":
+		return switchRouteNoop
+	case "/service79/user/profile":
+		return switchRouteNoop
+	case "/service80/user/profile":
+		return switchRouteNoop
+	case "/service81/user/profile":
+		return switchRouteNoop
+	case "/service82/user/profile":
+		return switchRouteNoop
+	case "/service83/user/profile":
+		return switchRouteNoop
+	case "/service84/user/profile":
+		return switchRouteNoop
+	case "/service85/user/profile":
+		return switchRouteNoop
+	case "/service86/user/profile":
+		return switchRouteNoop
+	case "/service87/user/profile":
+		return switchRouteNoop
+	case "/service88/user/profile":
+		return switchRouteNoop
+	case "/service89/user/profile":
+		return switchRouteNoop
+	case "/service90/user/profile":
+		return switchRouteNoop
+	case "/service91/user/profile":
+		return switchRouteNoop
+	case "/service92/user/profile":
+		return switchRouteNoop
+	case "/service93/user/profile":
+		return switchRouteNoop
+	case "/service94/user/profile":
+		return switchRouteNoop
+	case "/service95/user/profile":
+		return switchRouteNoop
+	case "/service96/user/profile":
+		return switchRouteNoop
+	case "/service97/user/profile":
+		return switchRouteNoop
+	case "/service98/user/profile":
+		return switchRouteNoop
+	case "/service99/user/profile":
+		return switchRouteNoop
+	}
+	return nil
+}
+
+func switchRoute1000(path string) func(*radixDummy, http.ResponseWriter, *http.Request, *ApiConfig) {
+	switch path {
+	case "/service0/user/profile":
+		return switchRouteNoop
+	case "/service1/user/profile":
+		return switchRouteNoop
+	case "/service2/user/profile":
+		return switchRouteNoop
+	case "/service3/user/profile":
+		return switchRouteNoop
+	case "/service4/user/profile":
+		return switchRouteNoop
+	case "/service5/user/profile":
+		return switchRouteNoop
+	case "/service6/user/profile":
+		return switchRouteNoop
+	case "/service7/user/profile":
+		return switchRouteNoop
+	case "/service8/user/profile":
+		return switchRouteNoop
+	case "/service9/user/profile":
+		return switchRouteNoop
+	case "/service10/user/profile":
+		return switchRouteNoop
+	case "/service11/user/profile":
+		return switchRouteNoop
+	case "/service12/user/profile":
+		return switchRouteNoop
+	case "/service13/user/profile":
+		return switchRouteNoop
+	case "/service14/user/profile":
+		return switchRouteNoop
+	case "/service15/user/profile":
+		return switchRouteNoop
+	case "/service16/user/profile":
+		return switchRouteNoop
+	case "/service17/user/profile":
+		return switchRouteNoop
+	case "/service18/user/profile":
+		return switchRouteNoop
+	case "/service19/user/profile":
+		return switchRouteNoop
+	case "/service20/user/profile":
+		return switchRouteNoop
+	case "/service21/user/profile":
+		return switchRouteNoop
+	case "/service22/user/profile":
+		return switchRouteNoop
+	case "/service23/user/profile":
+		return switchRouteNoop
+	case "/service24/user/profile":
+		return switchRouteNoop
+	case "/service25/user/profile":
+		return switchRouteNoop
+	case "/service26/user/profile":
+		return switchRouteNoop
+	case "/service27/user/profile":
+		return switchRouteNoop
+	case "/service28/user/profile":
+		return switchRouteNoop
+	case "/service29/user/profile":
+		return switchRouteNoop
+	case "/service30/user/profile":
+		return switchRouteNoop
+	case "/service31/user/profile":
+		return switchRouteNoop
+	case "/service32/user/profile":
+		return switchRouteNoop
+	case "/service33/user/profile":
+		return switchRouteNoop
+	case "/service34/user/profile":
+		return switchRouteNoop
+	case "/service35/user/profile":
+		return switchRouteNoop
+	case "/service36/user/profile":
+		return switchRouteNoop
+	case "/service37/user/profile":
+		return switchRouteNoop
+	case "/service38/user/profile":
+		return switchRouteNoop
+	case "/service39/user/profile":
+		return switchRouteNoop
+	case "/service40/user/profile":
+		return switchRouteNoop
+	case "/service41/user/profile":
+		return switchRouteNoop
+	case "/service42/user/profile":
+		return switchRouteNoop
+	case "/service43/user/profile":
+		return switchRouteNoop
+	case "/service44/user/profile":
+		return switchRouteNoop
+	case "/service45/user/profile":
+		return switchRouteNoop
+	case "/service46/user/profile":
+		return switchRouteNoop
+	case "/service47/user/profile":
+		return switchRouteNoop
+	case "/service48/user/profile":
+		return switchRouteNoop
+	case "/service49/user/profile":
+		return switchRouteNoop
+	case "/service50/user/profile":
+		return switchRouteNoop
+	case "/service51/user/profile":
+		return switchRouteNoop
+	case "/service52/user/profile":
+		return switchRouteNoop
+	case "/service53/user/profile":
+		return switchRouteNoop
+	case "/service54/user/profile":
+		return switchRouteNoop
+	case "/service55/user/profile":
+		return switchRouteNoop
+	case "/service56/user/profile":
+		return switchRouteNoop
+	case "/service57/user/profile":
+		return switchRouteNoop
+	case "/service58/user/profile":
+		return switchRouteNoop
+	case "/service59/user/profile":
+		return switchRouteNoop
+	case "/service60/user/profile":
+		return switchRouteNoop
+	case "/service61/user/profile":
+		return switchRouteNoop
+	case "/service62/user/profile":
+		return switchRouteNoop
+	case "/service63/user/profile":
+		return switchRouteNoop
+	case "/service64/user/profile":
+		return switchRouteNoop
+	case "/service65/user/profile":
+		return switchRouteNoop
+	case "/service66/user/profile":
+		return switchRouteNoop
+	case "/service67/user/profile":
+		return switchRouteNoop
+	case "/service68/user/profile":
+		return switchRouteNoop
+	case "/service69/user/profile":
+		return switchRouteNoop
+	case "/service70/user/profile":
+		return switchRouteNoop
+	case "/service71/user/profile":
+		return switchRouteNoop
+	case "/service72/user/profile":
+		return switchRouteNoop
+	case "/service73/user/profile":
+		return switchRouteNoop
+	case "/service74/user/profile":
+		return switchRouteNoop
+	case "/service75/user/profile":
+		return switchRouteNoop
+	case "/service76/user/profile":
+		return switchRouteNoop
+	case "/service77/user/profile":
+		return switchRouteNoop
+	case "/service78/user/profile":
+		return switchRouteNoop
+	case "/service79/user/profile":
+		return switchRouteNoop
+	case "/service80/user/profile":
+		return switchRouteNoop
+	case "/service81/user/profile":
+		return switchRouteNoop
+	case "/service82/user/profile":
+		return switchRouteNoop
+	case "/service83/user/profile":
+		return switchRouteNoop
+	case "/service84/user/profile":
+		return switchRouteNoop
+	case "/service85/user/profile":
+		return switchRouteNoop
+	case "/service86/user/profile":
+		return switchRouteNoop
+	case "/service87/user/profile":
+		return switchRouteNoop
+	case "/service88/user/profile":
+		return switchRouteNoop
+	case "/service89/user/profile":
+		return switchRouteNoop
+	case "/service90/user/profile":
+		return switchRouteNoop
+	case "/service91/user/profile":
+		return switchRouteNoop
+	case "/service92/user/profile":
+		return switchRouteNoop
+	case "/service93/user/profile":
+		return switchRouteNoop
+	case "/service94/user/profile":
+		return switchRouteNoop
+	case "/service95/user/profile":
+		return switchRouteNoop
+	case "/service96/user/profile":
+		return switchRouteNoop
+	case "/service97/user/profile":
+		return switchRouteNoop
+	case "/service98/user/profile":
+		return switchRouteNoop
+	case "/service99/user/profile":
+		return switchRouteNoop
+	case "/service100/user/profile":
+		return switchRouteNoop
+	case "/service101/user/profile":
+		return switchRouteNoop
+	case "/service102/user/profile":
+		return switchRouteNoop
+	case "/service103/user/profile":
+		return switchRouteNoop
+	case "/service104/user/profile":
+		return switchRouteNoop
+	case "/service105/user/profile":
+		return switchRouteNoop
+	case "/service106/user/profile":
+		return switchRouteNoop
+	case "/service107/user/profile":
+		return switchRouteNoop
+	case "/service108/user/profile":
+		return switchRouteNoop
+	case "/service109/user/profile":
+		return switchRouteNoop
+	case "/service110/user/profile":
+		return switchRouteNoop
+	case "/service111/user/profile":
+		return switchRouteNoop
+	case "/service112/user/profile":
+		return switchRouteNoop
+	case "/service113/user/profile":
+		return switchRouteNoop
+	case "/service114/user/profile":
+		return switchRouteNoop
+	case "/service115/user/profile":
+		return switchRouteNoop
+	case "/service116/user/profile":
+		return switchRouteNoop
+	case "/service117/user/profile":
+		return switchRouteNoop
+	case "/service118/user/profile":
+		return switchRouteNoop
+	case "/service119/user/profile":
+		return switchRouteNoop
+	case "/service120/user/profile":
+		return switchRouteNoop
+	case "/service121/user/profile":
+		return switchRouteNoop
+	case "/service122/user/profile":
+		return switchRouteNoop
+	case "/service123/user/profile":
+		return switchRouteNoop
+	case "/service124/user/profile":
+		return switchRouteNoop
+	case "/service125/user/profile":
+		return switchRouteNoop
+	case "/service126/user/profile":
+		return switchRouteNoop
+	case "/service127/user/profile":
+		return switchRouteNoop
+	case "/service128/user/profile":
+		return switchRouteNoop
+	case "/service129/user/profile":
+		return switchRouteNoop
+	case "/service130/user/profile":
+		return switchRouteNoop
+	case "/service131/user/profile":
+		return switchRouteNoop
+	case "/service132/user/profile":
+		return switchRouteNoop
+	case "/service133/user/profile":
+		return switchRouteNoop
+	case "/service134/user/profile":
+		return switchRouteNoop
+	case "/service135/user/profile":
+		return switchRouteNoop
+	case "/service136/user/profile":
+		return switchRouteNoop
+	case "/service137/user/profile":
+		return switchRouteNoop
+	case "/service138/user/profile":
+		return switchRouteNoop
+	case "/service139/user/profile":
+		return switchRouteNoop
+	case "/service140/user/profile":
+		return switchRouteNoop
+	case "/service141/user/profile":
+		return switchRouteNoop
+	case "/service142/user/profile":
+		return switchRouteNoop
+	case "/service143/user/profile":
+		return switchRouteNoop
+	case "/service144/user/profile":
+		return switchRouteNoop
+	case "/service145/user/profile":
+		return switchRouteNoop
+	case "/service146/user/profile":
+		return switchRouteNoop
+	case "/service147/user/profile":
+		return switchRouteNoop
+	case "/service148/user/profile":
+		return switchRouteNoop
+	case "/service149/user/profile":
+		return switchRouteNoop
+	case "/service150/user/profile":
+		return switchRouteNoop
+	case "/service151/user/profile":
+		return switchRouteNoop
+	case "/service152/user/profile":
+		return switchRouteNoop
+	case "/service153/user/profile":
+		return switchRouteNoop
+	case "/service154/user/profile":
+		return switchRouteNoop
+	case "/service155/user/profile":
+		return switchRouteNoop
+	case "/service156/user/profile":
+		return switchRouteNoop
+	case "/service157/user/profile":
+		return switchRouteNoop
+	case "/service158/user/profile":
+		return switchRouteNoop
+	case "/service159/user/profile":
+		return switchRouteNoop
+	case "/service160/user/profile":
+		return switchRouteNoop
+	case "/service161/user/profile":
+		return switchRouteNoop
+	case "/service162/user/profile":
+		return switchRouteNoop
+	case "/service163/user/profile":
+		return switchRouteNoop
+	case "/service164/user/profile":
+		return switchRouteNoop
+	case "/service165/user/profile":
+		return switchRouteNoop
+	case "/service166/user/profile":
+		return switchRouteNoop
+	case "/service167/user/profile":
+		return switchRouteNoop
+	case "/service168/user/profile":
+		return switchRouteNoop
+	case "/service169/user/profile":
+		return switchRouteNoop
+	case "/service170/user/profile":
+		return switchRouteNoop
+	case "/service171/user/profile":
+		return switchRouteNoop
+	case "/service172/user/profile":
+		return switchRouteNoop
+	case "/service173/user/profile":
+		return switchRouteNoop
+	case "/service174/user/profile":
+		return switchRouteNoop
+	case "/service175/user/profile":
+		return switchRouteNoop
+	case "/service176/user/profile":
+		return switchRouteNoop
+	case "/service177/user/profile":
+		return switchRouteNoop
+	case "/service178/user/profile":
+		return switchRouteNoop
+	case "/service179/user/profile":
+		return switchRouteNoop
+	case "/service180/user/profile":
+		return switchRouteNoop
+	case "/service181/user/profile":
+		return switchRouteNoop
+	case "/service182/user/profile":
+		return switchRouteNoop
+	case "/service183/user/profile":
+		return switchRouteNoop
+	case "/service184/user/profile":
+		return switchRouteNoop
+	case "/service185/user/profile":
+		return switchRouteNoop
+	case "/service186/user/profile":
+		return switchRouteNoop
+	case "/service187/user/profile":
+		return switchRouteNoop
+	case "/service188/user/profile":
+		return switchRouteNoop
+	case "/service189/user/profile":
+		return switchRouteNoop
+	case "/service190/user/profile":
+		return switchRouteNoop
+	case "/service191/user/profile":
+		return switchRouteNoop
+	case "/service192/user/profile":
+		return switchRouteNoop
+	case "/service193/user/profile":
+		return switchRouteNoop
+	case "/service194/user/profile":
+		return switchRouteNoop
+	case "/service195/user/profile":
+		return switchRouteNoop
+	case "/service196/user/profile":
+		return switchRouteNoop
+	case "/service197/user/profile":
+		return switchRouteNoop
+	case "/service198/user/profile":
+		return switchRouteNoop
+	case "/service199/user/profile":
+		return switchRouteNoop
+	case "/service200/user/profile":
+		return switchRouteNoop
+	case "/service201/user/profile":
+		return switchRouteNoop
+	case "/service202/user/profile":
+		return switchRouteNoop
+	case "/service203/user/profile":
+		return switchRouteNoop
+	case "/service204/user/profile":
+		return switchRouteNoop
+	case "/service205/user/profile":
+		return switchRouteNoop
+	case "/service206/user/profile":
+		return switchRouteNoop
+	case "/service207/user/profile":
+		return switchRouteNoop
+	case "/service208/user/profile":
+		return switchRouteNoop
+	case "/service209/user/profile":
+		return switchRouteNoop
+	case "/service210/user/profile":
+		return switchRouteNoop
+	case "/service211/user/profile":
+		return switchRouteNoop
+	case "/service212/user/profile":
+		return switchRouteNoop
+	case "/service213/user/profile":
+		return switchRouteNoop
+	case "/service214/user/profile":
+		return switchRouteNoop
+	case "/service215/user/profile":
+		return switchRouteNoop
+	case "/service216/user/profile":
+		return switchRouteNoop
+	case "/service217/user/profile":
+		return switchRouteNoop
+	case "/service218/user/profile":
+		return switchRouteNoop
+	case "/service219/user/profile":
+		return switchRouteNoop
+	case "/service220/user/profile":
+		return switchRouteNoop
+	case "/service221/user/profile":
+		return switchRouteNoop
+	case "/service222/user/profile":
+		return switchRouteNoop
+	case "/service223/user/profile":
+		return switchRouteNoop
+	case "/service224/user/profile":
+		return switchRouteNoop
+	case "/service225/user/profile":
+		return switchRouteNoop
+	case "/service226/user/profile":
+		return switchRouteNoop
+	case "/service227/user/profile":
+		return switchRouteNoop
+	case "/service228/user/profile":
+		return switchRouteNoop
+	case "/service229/user/profile":
+		return switchRouteNoop
+	case "/service230/user/profile":
+		return switchRouteNoop
+	case "/service231/user/profile":
+		return switchRouteNoop
+	case "/service232/user/profile":
+		return switchRouteNoop
+	case "/service233/user/profile":
+		return switchRouteNoop
+	case "/service234/user/profile":
+		return switchRouteNoop
+	case "/service235/user/profile":
+		return switchRouteNoop
+	case "/service236/user/profile":
+		return switchRouteNoop
+	case "/service237/user/profile":
+		return switchRouteNoop
+	case "/service238/user/profile":
+		return switchRouteNoop
+	case "/service239/user/profile":
+		return switchRouteNoop
+	case "/service240/user/profile":
+		return switchRouteNoop
+	case "/service241/user/profile":
+		return switchRouteNoop
+	case "/service242/user/profile":
+		return switchRouteNoop
+	case "/service243/user/profile":
+		return switchRouteNoop
+	case "/service244/user/profile":
+		return switchRouteNoop
+	case "/service245/user/profile":
+		return switchRouteNoop
+	case "/service246/user/profile":
+		return switchRouteNoop
+	case "/service247/user/profile":
+		return switchRouteNoop
+	case "/service248/user/profile":
+		return switchRouteNoop
+	case "/service249/user/profile":
+		return switchRouteNoop
+	case "/service250/user/profile":
+		return switchRouteNoop
+	case "/service251/user/profile":
+		return switchRouteNoop
+	case "/service252/user/profile":
+		return switchRouteNoop
+	case "/service253/user/profile":
+		return switchRouteNoop
+	case "/service254/user/profile":
+		return switchRouteNoop
+	case "/service255/user/profile":
+		return switchRouteNoop
+	case "/service256/user/profile":
+		return switchRouteNoop
+	case "/service257/user/profile":
+		return switchRouteNoop
+	case "/service258/user/profile":
+		return switchRouteNoop
+	case "/service259/user/profile":
+		return switchRouteNoop
+	case "/service260/user/profile":
+		return switchRouteNoop
+	case "/service261/user/profile":
+		return switchRouteNoop
+	case "/service262/user/profile":
+		return switchRouteNoop
+	case "/service263/user/profile":
+		return switchRouteNoop
+	case "/service264/user/profile":
+		return switchRouteNoop
+	case "/service265/user/profile":
+		return switchRouteNoop
+	case "/service266/user/profile":
+		return switchRouteNoop
+	case "/service267/user/profile":
+		return switchRouteNoop
+	case "/service268/user/profile":
+		return switchRouteNoop
+	case "/service269/user/profile":
+		return switchRouteNoop
+	case "/service270/user/profile":
+		return switchRouteNoop
+	case "/service271/user/profile":
+		return switchRouteNoop
+	case "/service272/user/profile":
+		return switchRouteNoop
+	case "/service273/user/profile":
+		return switchRouteNoop
+	case "/service274/user/profile":
+		return switchRouteNoop
+	case "/service275/user/profile":
+		return switchRouteNoop
+	case "/service276/user/profile":
+		return switchRouteNoop
+	case "/service277/user/profile":
+		return switchRouteNoop
+	case "/service278/user/profile":
+		return switchRouteNoop
+	case "/service279/user/profile":
+		return switchRouteNoop
+	case "/service280/user/profile":
+		return switchRouteNoop
+	case "/service281/user/profile":
+		return switchRouteNoop
+	case "/service282/user/profile":
+		return switchRouteNoop
+	case "/service283/user/profile":
+		return switchRouteNoop
+	case "/service284/user/profile":
+		return switchRouteNoop
+	case "/service285/user/profile":
+		return switchRouteNoop
+	case "/service286/user/profile":
+		return switchRouteNoop
+	case "/service287/user/profile":
+		return switchRouteNoop
+	case "/service288/user/profile":
+		return switchRouteNoop
+	case "/service289/user/profile":
+		return switchRouteNoop
+	case "/service290/user/profile":
+		return switchRouteNoop
+	case "/service291/user/profile":
+		return switchRouteNoop
+	case "/service292/user/profile":
+		return switchRouteNoop
+	case "/service293/user/profile":
+		return switchRouteNoop
+	case "/service294/user/profile":
+		return switchRouteNoop
+	case "/service295/user/profile":
+		return switchRouteNoop
+	case "/service296/user/profile":
+		return switchRouteNoop
+	case "/service297/user/profile":
+		return switchRouteNoop
+	case "/service298/user/profile":
+		return switchRouteNoop
+	case "/service299/user/profile":
+		return switchRouteNoop
+	case "/service300/user/profile":
+		return switchRouteNoop
+	case "/service301/user/profile":
+		return switchRouteNoop
+	case "/service302/user/profile":
+		return switchRouteNoop
+	case "/service303/user/profile":
+		return switchRouteNoop
+	case "/service304/user/profile":
+		return switchRouteNoop
+	case "/service305/user/profile":
+		return switchRouteNoop
+	case "/service306/user/profile":
+		return switchRouteNoop
+	case "/service307/user/profile":
+		return switchRouteNoop
+	case "/service308/user/profile":
+		return switchRouteNoop
+	case "/service309/user/profile":
+		return switchRouteNoop
+	case "/service310/user/profile":
+		return switchRouteNoop
+	case "/service311/user/profile":
+		return switchRouteNoop
+	case "/service312/user/profile":
+		return switchRouteNoop
+	case "/service313/user/profile":
+		return switchRouteNoop
+	case "/service314/user/profile":
+		return switchRouteNoop
+	case "/service315/user/profile":
+		return switchRouteNoop
+	case "/service316/user/profile":
+		return switchRouteNoop
+	case "/service317/user/profile":
+		return switchRouteNoop
+	case "/service318/user/profile":
+		return switchRouteNoop
+	case "/service319/user/profile":
+		return switchRouteNoop
+	case "/service320/user/profile":
+		return switchRouteNoop
+	case "/service321/user/profile":
+		return switchRouteNoop
+	case "/service322/user/profile":
+		return switchRouteNoop
+	case "/service323/user/profile":
+		return switchRouteNoop
+	case "/service324/user/profile":
+		return switchRouteNoop
+	case "/service325/user/profile":
+		return switchRouteNoop
+	case "/service326/user/profile":
+		return switchRouteNoop
+	case "/service327/user/profile":
+		return switchRouteNoop
+	case "/service328/user/profile":
+		return switchRouteNoop
+	case "/service329/user/profile":
+		return switchRouteNoop
+	case "/service330/user/profile":
+		return switchRouteNoop
+	case "/service331/user/profile":
+		return switchRouteNoop
+	case "/service332/user/profile":
+		return switchRouteNoop
+	case "/service333/user/profile":
+		return switchRouteNoop
+	case "/service334/user/profile":
+		return switchRouteNoop
+	case "/service335/user/profile":
+		return switchRouteNoop
+	case "/service336/user/profile":
+		return switchRouteNoop
+	case "/service337/user/profile":
+		return switchRouteNoop
+	case "/service338/user/profile":
+		return switchRouteNoop
+	case "/service339/user/profile":
+		return switchRouteNoop
+	case "/service340/user/profile":
+		return switchRouteNoop
+	case "/service341/user/profile":
+		return switchRouteNoop
+	case "/service342/user/profile":
+		return switchRouteNoop
+	case "/service343/user/profile":
+		return switchRouteNoop
+	case "/service344/user/profile":
+		return switchRouteNoop
+	case "/service345/user/profile":
+		return switchRouteNoop
+	case "/service346/user/profile":
+		return switchRouteNoop
+	case "/service347/user/profile":
+		return switchRouteNoop
+	case "/service348/user/profile":
+		return switchRouteNoop
+	case "/service349/user/profile":
+		return switchRouteNoop
+	case "/service350/user/profile":
+		return switchRouteNoop
+	case "/service351/user/profile":
+		return switchRouteNoop
+	case "/service352/user/profile":
+		return switchRouteNoop
+	case "/service353/user/profile":
+		return switchRouteNoop
+	case "/service354/user/profile":
+		return switchRouteNoop
+	case "/service355/user/profile":
+		return switchRouteNoop
+	case "/service356/user/profile":
+		return switchRouteNoop
+	case "/service357/user/profile":
+		return switchRouteNoop
+	case "/service358/user/profile":
+		return switchRouteNoop
+	case "/service359/user/profile":
+		return switchRouteNoop
+	case "/service360/user/profile":
+		return switchRouteNoop
+	case "/service361/user/profile":
+		return switchRouteNoop
+	case "/service362/user/profile":
+		return switchRouteNoop
+	case "/service363/user/profile":
+		return switchRouteNoop
+	case "/service364/user/profile":
+		return switchRouteNoop
+	case "/service365/user/profile":
+		return switchRouteNoop
+	case "/service366/user/profile":
+		return switchRouteNoop
+	case "/service367/user/profile":
+		return switchRouteNoop
+	case "/service368/user/profile":
+		return switchRouteNoop
+	case "/service369/user/profile":
+		return switchRouteNoop
+	case "/service370/user/profile":
+		return switchRouteNoop
+	case "/service371/user/profile":
+		return switchRouteNoop
+	case "/service372/user/profile":
+		return switchRouteNoop
+	case "/service373/user/profile":
+		return switchRouteNoop
+	case "/service374/user/profile":
+		return switchRouteNoop
+	case "/service375/user/profile":
+		return switchRouteNoop
+	case "/service376/user/profile":
+		return switchRouteNoop
+	case "/service377/user/profile":
+		return switchRouteNoop
+	case "/service378/user/profile":
+		return switchRouteNoop
+	case "/service379/user/profile":
+		return switchRouteNoop
+	case "/service380/user/profile":
+		return switchRouteNoop
+	case "/service381/user/profile":
+		return switchRouteNoop
+	case "/service382/user/profile":
+		return switchRouteNoop
+	case "/service383/user/profile":
+		return switchRouteNoop
+	case "/service384/user/profile":
+		return switchRouteNoop
+	case "/service385/user/profile":
+		return switchRouteNoop
+	case "/service386/user/profile":
+		return switchRouteNoop
+	case "/service387/user/profile":
+		return switchRouteNoop
+	case "/service388/user/profile":
+		return switchRouteNoop
+	case "/service389/user/profile":
+		return switchRouteNoop
+	case "/service390/user/profile":
+		return switchRouteNoop
+	case "/service391/user/profile":
+		return switchRouteNoop
+	case "/service392/user/profile":
+		return switchRouteNoop
+	case "/service393/user/profile":
+		return switchRouteNoop
+	case "/service394/user/profile":
+		return switchRouteNoop
+	case "/service395/user/profile":
+		return switchRouteNoop
+	case "/service396/user/profile":
+		return switchRouteNoop
+	case "/service397/user/profile":
+		return switchRouteNoop
+	case "/service398/user/profile":
+		return switchRouteNoop
+	case "/service399/user/profile":
+		return switchRouteNoop
+	case "/service400/user/profile":
+		return switchRouteNoop
+	case "/service401/user/profile":
+		return switchRouteNoop
+	case "/service402/user/profile":
+		return switchRouteNoop
+	case "/service403/user/profile":
+		return switchRouteNoop
+	case "/service404/user/profile":
+		return switchRouteNoop
+	case "/service405/user/profile":
+		return switchRouteNoop
+	case "/service406/user/profile":
+		return switchRouteNoop
+	case "/service407/user/profile":
+		return switchRouteNoop
+	case "/service408/user/profile":
+		return switchRouteNoop
+	case "/service409/user/profile":
+		return switchRouteNoop
+	case "/service410/user/profile":
+		return switchRouteNoop
+	case "/service411/user/profile":
+		return switchRouteNoop
+	case "/service412/user/profile":
+		return switchRouteNoop
+	case "/service413/user/profile":
+		return switchRouteNoop
+	case "/service414/user/profile":
+		return switchRouteNoop
+	case "/service415/user/profile":
+		return switchRouteNoop
+	case "/service416/user/profile":
+		return switchRouteNoop
+	case "/service417/user/profile":
+		return switchRouteNoop
+	case "/service418/user/profile":
+		return switchRouteNoop
+	case "/service419/user/profile":
+		return switchRouteNoop
+	case "/service420/user/profile":
+		return switchRouteNoop
+	case "/service421/user/profile":
+		return switchRouteNoop
+	case "/service422/user/profile":
+		return switchRouteNoop
+	case "/service423/user/profile":
+		return switchRouteNoop
+	case "/service424/user/profile":
+		return switchRouteNoop
+	case "/service425/user/profile":
+		return switchRouteNoop
+	case "/service426/user/profile":
+		return switchRouteNoop
+	case "/service427/user/profile":
+		return switchRouteNoop
+	case "/service428/user/profile":
+		return switchRouteNoop
+	case "/service429/user/profile":
+		return switchRouteNoop
+	case "/service430/user/profile":
+		return switchRouteNoop
+	case "/service431/user/profile":
+		return switchRouteNoop
+	case "/service432/user/profile":
+		return switchRouteNoop
+	case "/service433/user/profile":
+		return switchRouteNoop
+	case "/service434/user/profile":
+		return switchRouteNoop
+	case "/service435/user/profile":
+		return switchRouteNoop
+	case "/service436/user/profile":
+		return switchRouteNoop
+	case "/service437/user/profile":
+		return switchRouteNoop
+	case "/service438/user/profile":
+		return switchRouteNoop
+	case "/service439/user/profile":
+		return switchRouteNoop
+	case "/service440/user/profile":
+		return switchRouteNoop
+	case "/service441/user/profile":
+		return switchRouteNoop
+	case "/service442/user/profile":
+		return switchRouteNoop
+	case "/service443/user/profile":
+		return switchRouteNoop
+	case "/service444/user/profile":
+		return switchRouteNoop
+	case "/service445/user/profile":
+		return switchRouteNoop
+	case "/service446/user/profile":
+		return switchRouteNoop
+	case "/service447/user/profile":
+		return switchRouteNoop
+	case "/service448/user/profile":
+		return switchRouteNoop
+	case "/service449/user/profile":
+		return switchRouteNoop
+	case "/service450/user/profile":
+		return switchRouteNoop
+	case "/service451/user/profile":
+		return switchRouteNoop
+	case "/service452/user/profile":
+		return switchRouteNoop
+	case "/service453/user/profile":
+		return switchRouteNoop
+	case "/service454/user/profile":
+		return switchRouteNoop
+	case "/service455/user/profile":
+		return switchRouteNoop
+	case "/service456/user/profile":
+		return switchRouteNoop
+	case "/service457/user/profile":
+		return switchRouteNoop
+	case "/service458/user/profile":
+		return switchRouteNoop
+	case "/service459/user/profile":
+		return switchRouteNoop
+	case "/service460/user/profile":
+		return switchRouteNoop
+	case "/service461/user/profile":
+		return switchRouteNoop
+	case "/service462/user/profile":
+		return switchRouteNoop
+	case "/service463/user/profile":
+		return switchRouteNoop
+	case "/service464/user/profile":
+		return switchRouteNoop
+	case "/service465/user/profile":
+		return switchRouteNoop
+	case "/service466/user/profile":
+		return switchRouteNoop
+	case "/service467/user/profile":
+		return switchRouteNoop
+	case "/service468/user/profile":
+		return switchRouteNoop
+	case "/service469/user/profile":
+		return switchRouteNoop
+	case "/service470/user/profile":
+		return switchRouteNoop
+	case "/service471/user/profile":
+		return switchRouteNoop
+	case "/service472/user/profile":
+		return switchRouteNoop
+	case "/service473/user/profile":
+		return switchRouteNoop
+	case "/service474/user/profile":
+		return switchRouteNoop
+	case "/service475/user/profile":
+		return switchRouteNoop
+	case "/service476/user/profile":
+		return switchRouteNoop
+	case "/service477/user/profile":
+		return switchRouteNoop
+	case "/service478/user/profile":
+		return switchRouteNoop
+	case "/service479/user/profile":
+		return switchRouteNoop
+	case "/service480/user/profile":
+		return switchRouteNoop
+	case "/service481/user/profile":
+		return switchRouteNoop
+	case "/service482/user/profile":
+		return switchRouteNoop
+	case "/service483/user/profile":
+		return switchRouteNoop
+	case "/service484/user/profile":
+		return switchRouteNoop
+	case "/service485/user/profile":
+		return switchRouteNoop
+	case "/service486/user/profile":
+		return switchRouteNoop
+	case "/service487/user/profile":
+		return switchRouteNoop
+	case "/service488/user/profile":
+		return switchRouteNoop
+	case "/service489/user/profile":
+		return switchRouteNoop
+	case "/service490/user/profile":
+		return switchRouteNoop
+	case "/service491/user/profile":
+		return switchRouteNoop
+	case "/service492/user/profile":
+		return switchRouteNoop
+	case "/service493/user/profile":
+		return switchRouteNoop
+	case "/service494/user/profile":
+		return switchRouteNoop
+	case "/service495/user/profile":
+		return switchRouteNoop
+	case "/service496/user/profile":
+		return switchRouteNoop
+	case "/service497/user/profile":
+		return switchRouteNoop
+	case "/service498/user/profile":
+		return switchRouteNoop
+	case "/service499/user/profile":
+		return switchRouteNoop
+	case "/service500/user/profile":
+		return switchRouteNoop
+	case "/service501/user/profile":
+		return switchRouteNoop
+	case "/service502/user/profile":
+		return switchRouteNoop
+	case "/service503/user/profile":
+		return switchRouteNoop
+	case "/service504/user/profile":
+		return switchRouteNoop
+	case "/service505/user/profile":
+		return switchRouteNoop
+	case "/service506/user/profile":
+		return switchRouteNoop
+	case "/service507/user/profile":
+		return switchRouteNoop
+	case "/service508/user/profile":
+		return switchRouteNoop
+	case "/service509/user/profile":
+		return switchRouteNoop
+	case "/service510/user/profile":
+		return switchRouteNoop
+	case "/service511/user/profile":
+		return switchRouteNoop
+	case "/service512/user/profile":
+		return switchRouteNoop
+	case "/service513/user/profile":
+		return switchRouteNoop
+	case "/service514/user/profile":
+		return switchRouteNoop
+	case "/service515/user/profile":
+		return switchRouteNoop
+	case "/service516/user/profile":
+		return switchRouteNoop
+	case "/service517/user/profile":
+		return switchRouteNoop
+	case "/service518/user/profile":
+		return switchRouteNoop
+	case "/service519/user/profile":
+		return switchRouteNoop
+	case "/service520/user/profile":
+		return switchRouteNoop
+	case "/service521/user/profile":
+		return switchRouteNoop
+	case "/service522/user/profile":
+		return switchRouteNoop
+	case "/service523/user/profile":
+		return switchRouteNoop
+	case "/service524/user/profile":
+		return switchRouteNoop
+	case "/service525/user/profile":
+		return switchRouteNoop
+	case "/service526/user/profile":
+		return switchRouteNoop
+	case "/service527/user/profile":
+		return switchRouteNoop
+	case "/service528/user/profile":
+		return switchRouteNoop
+	case "/service529/user/profile":
+		return switchRouteNoop
+	case "/service530/user/profile":
+		return switchRouteNoop
+	case "/service531/user/profile":
+		return switchRouteNoop
+	case "/service532/user/profile":
+		return switchRouteNoop
+	case "/service533/user/profile":
+		return switchRouteNoop
+	case "/service534/user/profile":
+		return switchRouteNoop
+	case "/service535/user/profile":
+		return switchRouteNoop
+	case "/service536/user/profile":
+		return switchRouteNoop
+	case "/service537/user/profile":
+		return switchRouteNoop
+	case "/service538/user/profile":
+		return switchRouteNoop
+	case "/service539/user/profile":
+		return switchRouteNoop
+	case "/service540/user/profile":
+		return switchRouteNoop
+	case "/service541/user/profile":
+		return switchRouteNoop
+	case "/service542/user/profile":
+		return switchRouteNoop
+	case "/service543/user/profile":
+		return switchRouteNoop
+	case "/service544/user/profile":
+		return switchRouteNoop
+	case "/service545/user/profile":
+		return switchRouteNoop
+	case "/service546/user/profile":
+		return switchRouteNoop
+	case "/service547/user/profile":
+		return switchRouteNoop
+	case "/service548/user/profile":
+		return switchRouteNoop
+	case "/service549/user/profile":
+		return switchRouteNoop
+	case "/service550/user/profile":
+		return switchRouteNoop
+	case "/service551/user/profile":
+		return switchRouteNoop
+	case "/service552/user/profile":
+		return switchRouteNoop
+	case "/service553/user/profile":
+		return switchRouteNoop
+	case "/service554/user/profile":
+		return switchRouteNoop
+	case "/service555/user/profile":
+		return switchRouteNoop
+	case "/service556/user/profile":
+		return switchRouteNoop
+	case "/service557/user/profile":
+		return switchRouteNoop
+	case "/service558/user/profile":
+		return switchRouteNoop
+	case "/service559/user/profile":
+		return switchRouteNoop
+	case "/service560/user/profile":
+		return switchRouteNoop
+	case "/service561/user/profile":
+		return switchRouteNoop
+	case "/service562/user/profile":
+		return switchRouteNoop
+	case "/service563/user/profile":
+		return switchRouteNoop
+	case "/service564/user/profile":
+		return switchRouteNoop
+	case "/service565/user/profile":
+		return switchRouteNoop
+	case "/service566/user/profile":
+		return switchRouteNoop
+	case "/service567/user/profile":
+		return switchRouteNoop
+	case "/service568/user/profile":
+		return switchRouteNoop
+	case "/service569/user/profile":
+		return switchRouteNoop
+	case "/service570/user/profile":
+		return switchRouteNoop
+	case "/service571/user/profile":
+		return switchRouteNoop
+	case "/service572/user/profile":
+		return switchRouteNoop
+	case "/service573/user/profile":
+		return switchRouteNoop
+	case "/service574/user/profile":
+		return switchRouteNoop
+	case "/service575/user/profile":
+		return switchRouteNoop
+	case "/service576/user/profile":
+		return switchRouteNoop
+	case "/service577/user/profile":
+		return switchRouteNoop
+	case "/service578/user/profile":
+		return switchRouteNoop
+	case "/service579/user/profile":
+		return switchRouteNoop
+	case "/service580/user/profile":
+		return switchRouteNoop
+	case "/service581/user/profile":
+		return switchRouteNoop
+	case "/service582/user/profile":
+		return switchRouteNoop
+	case "/service583/user/profile":
+		return switchRouteNoop
+	case "/service584/user/profile":
+		return switchRouteNoop
+	case "/service585/user/profile":
+		return switchRouteNoop
+	case "/service586/user/profile":
+		return switchRouteNoop
+	case "/service587/user/profile":
+		return switchRouteNoop
+	case "/service588/user/profile":
+		return switchRouteNoop
+	case "/service589/user/profile":
+		return switchRouteNoop
+	case "/service590/user/profile":
+		return switchRouteNoop
+	case "/service591/user/profile":
+		return switchRouteNoop
+	case "/service592/user/profile":
+		return switchRouteNoop
+	case "/service593/user/profile":
+		return switchRouteNoop
+	case "/service594/user/profile":
+		return switchRouteNoop
+	case "/service595/user/profile":
+		return switchRouteNoop
+	case "/service596/user/profile":
+		return switchRouteNoop
+	case "/service597/user/profile":
+		return switchRouteNoop
+	case "/service598/user/profile":
+		return switchRouteNoop
+	case "/service599/user/profile":
+		return switchRouteNoop
+	case "/service600/user/profile":
+		return switchRouteNoop
+	case "/service601/user/profile":
+		return switchRouteNoop
+	case "/service602/user/profile":
+		return switchRouteNoop
+	case "/service603/user/profile":
+		return switchRouteNoop
+	case "/service604/user/profile":
+		return switchRouteNoop
+	case "/service605/user/profile":
+		return switchRouteNoop
+	case "/service606/user/profile":
+		return switchRouteNoop
+	case "/service607/user/profile":
+		return switchRouteNoop
+	case "/service608/user/profile":
+		return switchRouteNoop
+	case "/service609/user/profile":
+		return switchRouteNoop
+	case "/service610/user/profile":
+		return switchRouteNoop
+	case "/service611/user/profile":
+		return switchRouteNoop
+	case "/service612/user/profile":
+		return switchRouteNoop
+	case "/service613/user/profile":
+		return switchRouteNoop
+	case "/service614/user/profile":
+		return switchRouteNoop
+	case "/service615/user/profile":
+		return switchRouteNoop
+	case "/service616/user/profile":
+		return switchRouteNoop
+	case "/service617/user/profile":
+		return switchRouteNoop
+	case "/service618/user/profile":
+		return switchRouteNoop
+	case "/service619/user/profile":
+		return switchRouteNoop
+	case "/service620/user/profile":
+		return switchRouteNoop
+	case "/service621/user/profile":
+		return switchRouteNoop
+	case "/service622/user/profile":
+		return switchRouteNoop
+	case "/service623/user/profile":
+		return switchRouteNoop
+	case "/service624/user/profile":
+		return switchRouteNoop
+	case "/service625/user/profile":
+		return switchRouteNoop
+	case "/service626/user/profile":
+		return switchRouteNoop
+	case "/service627/user/profile":
+		return switchRouteNoop
+	case "/service628/user/profile":
+		return switchRouteNoop
+	case "/service629/user/profile":
+		return switchRouteNoop
+	case "/service630/user/profile":
+		return switchRouteNoop
+	case "/service631/user/profile":
+		return switchRouteNoop
+	case "/service632/user/profile":
+		return switchRouteNoop
+	case "/service633/user/profile":
+		return switchRouteNoop
+	case "/service634/user/profile":
+		return switchRouteNoop
+	case "/service635/user/profile":
+		return switchRouteNoop
+	case "/service636/user/profile":
+		return switchRouteNoop
+	case "/service637/user/profile":
+		return switchRouteNoop
+	case "/service638/user/profile":
+		return switchRouteNoop
+	case "/service639/user/profile":
+		return switchRouteNoop
+	case "/service640/user/profile":
+		return switchRouteNoop
+	case "/service641/user/profile":
+		return switchRouteNoop
+	case "/service642/user/profile":
+		return switchRouteNoop
+	case "/service643/user/profile":
+		return switchRouteNoop
+	case "/service644/user/profile":
+		return switchRouteNoop
+	case "/service645/user/profile":
+		return switchRouteNoop
+	case "/service646/user/profile":
+		return switchRouteNoop
+	case "/service647/user/profile":
+		return switchRouteNoop
+	case "/service648/user/profile":
+		return switchRouteNoop
+	case "/service649/user/profile":
+		return switchRouteNoop
+	case "/service650/user/profile":
+		return switchRouteNoop
+	case "/service651/user/profile":
+		return switchRouteNoop
+	case "/service652/user/profile":
+		return switchRouteNoop
+	case "/service653/user/profile":
+		return switchRouteNoop
+	case "/service654/user/profile":
+		return switchRouteNoop
+	case "/service655/user/profile":
+		return switchRouteNoop
+	case "/service656/user/profile":
+		return switchRouteNoop
+	case "/service657/user/profile":
+		return switchRouteNoop
+	case "/service658/user/profile":
+		return switchRouteNoop
+	case "/service659/user/profile":
+		return switchRouteNoop
+	case "/service660/user/profile":
+		return switchRouteNoop
+	case "/service661/user/profile":
+		return switchRouteNoop
+	case "/service662/user/profile":
+		return switchRouteNoop
+	case "/service663/user/profile":
+		return switchRouteNoop
+	case "/service664/user/profile":
+		return switchRouteNoop
+	case "/service665/user/profile":
+		return switchRouteNoop
+	case "/service666/user/profile":
+		return switchRouteNoop
+	case "/service667/user/profile":
+		return switchRouteNoop
+	case "/service668/user/profile":
+		return switchRouteNoop
+	case "/service669/user/profile":
+		return switchRouteNoop
+	case "/service670/user/profile":
+		return switchRouteNoop
+	case "/service671/user/profile":
+		return switchRouteNoop
+	case "/service672/user/profile":
+		return switchRouteNoop
+	case "/service673/user/profile":
+		return switchRouteNoop
+	case "/service674/user/profile":
+		return switchRouteNoop
+	case "/service675/user/profile":
+		return switchRouteNoop
+	case "/service676/user/profile":
+		return switchRouteNoop
+	case "/service677/user/profile":
+		return switchRouteNoop
+	case "/service678/user/profile":
+		return switchRouteNoop
+	case "/service679/user/profile":
+		return switchRouteNoop
+	case "/service680/user/profile":
+		return switchRouteNoop
+	case "/service681/user/profile":
+		return switchRouteNoop
+	case "/service682/user/profile":
+		return switchRouteNoop
+	case "/service683/user/profile":
+		return switchRouteNoop
+	case "/service684/user/profile":
+		return switchRouteNoop
+	case "/service685/user/profile":
+		return switchRouteNoop
+	case "/service686/user/profile":
+		return switchRouteNoop
+	case "/service687/user/profile":
+		return switchRouteNoop
+	case "/service688/user/profile":
+		return switchRouteNoop
+	case "/service689/user/profile":
+		return switchRouteNoop
+	case "/service690/user/profile":
+		return switchRouteNoop
+	case "/service691/user/profile":
+		return switchRouteNoop
+	case "/service692/user/profile":
+		return switchRouteNoop
+	case "/service693/user/profile":
+		return switchRouteNoop
+	case "/service694/user/profile":
+		return switchRouteNoop
+	case "/service695/user/profile":
+		return switchRouteNoop
+	case "/service696/user/profile":
+		return switchRouteNoop
+	case "/service697/user/profile":
+		return switchRouteNoop
+	case "/service698/user/profile":
+		return switchRouteNoop
+	case "/service699/user/profile":
+		return switchRouteNoop
+	case "/service700/user/profile":
+		return switchRouteNoop
+	case "/service701/user/profile":
+		return switchRouteNoop
+	case "/service702/user/profile":
+		return switchRouteNoop
+	case "/service703/user/profile":
+		return switchRouteNoop
+	case "/service704/user/profile":
+		return switchRouteNoop
+	case "/service705/user/profile":
+		return switchRouteNoop
+	case "/service706/user/profile":
+		return switchRouteNoop
+	case "/service707/user/profile":
+		return switchRouteNoop
+	case "/service708/user/profile":
+		return switchRouteNoop
+	case "/service709/user/profile":
+		return switchRouteNoop
+	case "/service710/user/profile":
+		return switchRouteNoop
+	case "/service711/user/profile":
+		return switchRouteNoop
+	case "/service712/user/profile":
+		return switchRouteNoop
+	case "/service713/user/profile":
+		return switchRouteNoop
+	case "/service714/user/profile":
+		return switchRouteNoop
+	case "/service715/user/profile":
+		return switchRouteNoop
+	case "/service716/user/profile":
+		return switchRouteNoop
+	case "/service717/user/profile":
+		return switchRouteNoop
+	case "/service718/user/profile":
+		return switchRouteNoop
+	case "/service719/user/profile":
+		return switchRouteNoop
+	case "/service720/user/profile":
+		return switchRouteNoop
+	case "/service721/user/profile":
+		return switchRouteNoop
+	case "/service722/user/profile":
+		return switchRouteNoop
+	case "/service723/user/profile":
+		return switchRouteNoop
+	case "/service724/user/profile":
+		return switchRouteNoop
+	case "/service725/user/profile":
+		return switchRouteNoop
+	case "/service726/user/profile":
+		return switchRouteNoop
+	case "/service727/user/profile":
+		return switchRouteNoop
+	case "/service728/user/profile":
+		return switchRouteNoop
+	case "/service729/user/profile":
+		return switchRouteNoop
+	case "/service730/user/profile":
+		return switchRouteNoop
+	case "/service731/user/profile":
+		return switchRouteNoop
+	case "/service732/user/profile":
+		return switchRouteNoop
+	case "/service733/user/profile":
+		return switchRouteNoop
+	case "/service734/user/profile":
+		return switchRouteNoop
+	case "/service735/user/profile":
+		return switchRouteNoop
+	case "/service736/user/profile":
+		return switchRouteNoop
+	case "/service737/user/profile":
+		return switchRouteNoop
+	case "/service738/user/profile":
+		return switchRouteNoop
+	case "/service739/user/profile":
+		return switchRouteNoop
+	case "/service740/user/profile":
+		return switchRouteNoop
+	case "/service741/user/profile":
+		return switchRouteNoop
+	case "/service742/user/profile":
+		return switchRouteNoop
+	case "/service743/user/profile":
+		return switchRouteNoop
+	case "/service744/user/profile":
+		return switchRouteNoop
+	case "/service745/user/profile":
+		return switchRouteNoop
+	case "/service746/user/profile":
+		return switchRouteNoop
+	case "/service747/user/profile":
+		return switchRouteNoop
+	case "/service748/user/profile":
+		return switchRouteNoop
+	case "/service749/user/profile":
+		return switchRouteNoop
+	case "/service750/user/profile":
+		return switchRouteNoop
+	case "/service751/user/profile":
+		return switchRouteNoop
+	case "/service752/user/profile":
+		return switchRouteNoop
+	case "/service753/user/profile":
+		return switchRouteNoop
+	case "/service754/user/profile":
+		return switchRouteNoop
+	case "/service755/user/profile":
+		return switchRouteNoop
+	case "/service756/user/profile":
+		return switchRouteNoop
+	case "/service757/user/profile":
+		return switchRouteNoop
+	case "/service758/user/profile":
+		return switchRouteNoop
+	case "/service759/user/profile":
+		return switchRouteNoop
+	case "/service760/user/profile":
+		return switchRouteNoop
+	case "/service761/user/profile":
+		return switchRouteNoop
+	case "/service762/user/profile":
+		return switchRouteNoop
+	case "/service763/user/profile":
+		return switchRouteNoop
+	case "/service764/user/profile":
+		return switchRouteNoop
+	case "/service765/user/profile":
+		return switchRouteNoop
+	case "/service766/user/profile":
+		return switchRouteNoop
+	case "/service767/user/profile":
+		return switchRouteNoop
+	case "/service768/user/profile":
+		return switchRouteNoop
+	case "/service769/user/profile":
+		return switchRouteNoop
+	case "/service770/user/profile":
+		return switchRouteNoop
+	case "/service771/user/profile":
+		return switchRouteNoop
+	case "/service772/user/profile":
+		return switchRouteNoop
+	case "/service773/user/profile":
+		return switchRouteNoop
+	case "/service774/user/profile":
+		return switchRouteNoop
+	case "/service775/user/profile":
+		return switchRouteNoop
+	case "/service776/user/profile":
+		return switchRouteNoop
+	case "/service777/user/profile":
+		return switchRouteNoop
+	case "/service778/user/profile":
+		return switchRouteNoop
+	case "/service779/user/profile":
+		return switchRouteNoop
+	case "/service780/user/profile":
+		return switchRouteNoop
+	case "/service781/user/profile":
+		return switchRouteNoop
+	case "/service782/user/profile":
+		return switchRouteNoop
+	case "/service783/user/profile":
+		return switchRouteNoop
+	case "/service784/user/profile":
+		return switchRouteNoop
+	case "/service785/user/profile":
+		return switchRouteNoop
+	case "/service786/user/profile":
+		return switchRouteNoop
+	case "/service787/user/profile":
+		return switchRouteNoop
+	case "/service788/user/profile":
+		return switchRouteNoop
+	case "/service789/user/profile":
+		return switchRouteNoop
+	case "/service790/user/profile":
+		return switchRouteNoop
+	case "/service791/user/profile":
+		return switchRouteNoop
+	case "/service792/user/profile":
+		return switchRouteNoop
+	case "/service793/user/profile":
+		return switchRouteNoop
+	case "/service794/user/profile":
+		return switchRouteNoop
+	case "/service795/user/profile":
+		return switchRouteNoop
+	case "/service796/user/profile":
+		return switchRouteNoop
+	case "/service797/user/profile":
+		return switchRouteNoop
+	case "/service798/user/profile":
+		return switchRouteNoop
+	case "/service799/user/profile":
+		return switchRouteNoop
+	case "/service800/user/profile":
+		return switchRouteNoop
+	case "/service801/user/profile":
+		return switchRouteNoop
+	case "/service802/user/profile":
+		return switchRouteNoop
+	case "/service803/user/profile":
+		return switchRouteNoop
+	case "/service804/user/profile":
+		return switchRouteNoop
+	case "/service805/user/profile":
+		return switchRouteNoop
+	case "/service806/user/profile":
+		return switchRouteNoop
+	case "/service807/user/profile":
+		return switchRouteNoop
+	case "/service808/user/profile":
+		return switchRouteNoop
+	case "/service809/user/profile":
+		return switchRouteNoop
+	case "/service810/user/profile":
+		return switchRouteNoop
+	case "/service811/user/profile":
+		return switchRouteNoop
+	case "/service812/user/profile":
+		return switchRouteNoop
+	case "/service813/user/profile":
+		return switchRouteNoop
+	case "/service814/user/profile":
+		return switchRouteNoop
+	case "/service815/user/profile":
+		return switchRouteNoop
+	case "/service816/user/profile":
+		return switchRouteNoop
+	case "/service817/user/profile":
+		return switchRouteNoop
+	case "/service818/user/profile":
+		return switchRouteNoop
+	case "/service819/user/profile":
+		return switchRouteNoop
+	case "/service820/user/profile":
+		return switchRouteNoop
+	case "/service821/user/profile":
+		return switchRouteNoop
+	case "/service822/user/profile":
+		return switchRouteNoop
+	case "/service823/user/profile":
+		return switchRouteNoop
+	case "/service824/user/profile":
+		return switchRouteNoop
+	case "/service825/user/profile":
+		return switchRouteNoop
+	case "/service826/user/profile":
+		return switchRouteNoop
+	case "/service827/user/profile":
+		return switchRouteNoop
+	case "/service828/user/profile":
+		return switchRouteNoop
+	case "/service829/user/profile":
+		return switchRouteNoop
+	case "/service830/user/profile":
+		return switchRouteNoop
+	case "/service831/user/profile":
+		return switchRouteNoop
+	case "/service832/user/profile":
+		return switchRouteNoop
+	case "/service833/user/profile":
+		return switchRouteNoop
+	case "/service834/user/profile":
+		return switchRouteNoop
+	case "/service835/user/profile":
+		return switchRouteNoop
+	case "/service836/user/profile":
+		return switchRouteNoop
+	case "/service837/user/profile":
+		return switchRouteNoop
+	case "/service838/user/profile":
+		return switchRouteNoop
+	case "/service839/user/profile":
+		return switchRouteNoop
+	case "/service840/user/profile":
+		return switchRouteNoop
+	case "/service841/user/profile":
+		return switchRouteNoop
+	case "/service842/user/profile":
+		return switchRouteNoop
+	case "/service843/user/profile":
+		return switchRouteNoop
+	case "/service844/user/profile":
+		return switchRouteNoop
+	case "/service845/user/profile":
+		return switchRouteNoop
+	case "/service846/user/profile":
+		return switchRouteNoop
+	case "/service847/user/profile":
+		return switchRouteNoop
+	case "/service848/user/profile":
+		return switchRouteNoop
+	case "/service849/user/profile":
+		return switchRouteNoop
+	case "/service850/user/profile":
+		return switchRouteNoop
+	case "/service851/user/profile":
+		return switchRouteNoop
+	case "/service852/user/profile":
+		return switchRouteNoop
+	case "/service853/user/profile":
+		return switchRouteNoop
+	case "/service854/user/profile":
+		return switchRouteNoop
+	case "/service855/user/profile":
+		return switchRouteNoop
+	case "/service856/user/profile":
+		return switchRouteNoop
+	case "/service857/user/profile":
+		return switchRouteNoop
+	case "/service858/user/profile":
+		return switchRouteNoop
+	case "/service859/user/profile":
+		return switchRouteNoop
+	case "/service860/user/profile":
+		return switchRouteNoop
+	case "/service861/user/profile":
+		return switchRouteNoop
+	case "/service862/user/profile":
+		return switchRouteNoop
+	case "/service863/user/profile":
+		return switchRouteNoop
+	case "/service864/user/profile":
+		return switchRouteNoop
+	case "/service865/user/profile":
+		return switchRouteNoop
+	case "/service866/user/profile":
+		return switchRouteNoop
+	case "/service867/user/profile":
+		return switchRouteNoop
+	case "/service868/user/profile":
+		return switchRouteNoop
+	case "/service869/user/profile":
+		return switchRouteNoop
+	case "/service870/user/profile":
+		return switchRouteNoop
+	case "/service871/user/profile":
+		return switchRouteNoop
+	case "/service872/user/profile":
+		return switchRouteNoop
+	case "/service873/user/profile":
+		return switchRouteNoop
+	case "/service874/user/profile":
+		return switchRouteNoop
+	case "/service875/user/profile":
+		return switchRouteNoop
+	case "/service876/user/profile":
+		return switchRouteNoop
+	case "/service877/user/profile":
+		return switchRouteNoop
+	case "/service878/user/profile":
+		return switchRouteNoop
+	case "/service879/user/profile":
+		return switchRouteNoop
+	case "/service880/user/profile":
+		return switchRouteNoop
+	case "/service881/user/profile":
+		return switchRouteNoop
+	case "/service882/user/profile":
+		return switchRouteNoop
+	case "/service883/user/profile":
+		return switchRouteNoop
+	case "/service884/user/profile":
+		return switchRouteNoop
+	case "/service885/user/profile":
+		return switchRouteNoop
+	case "/service886/user/profile":
+		return switchRouteNoop
+	case "/service887/user/profile":
+		return switchRouteNoop
+	case "/service888/user/profile":
+		return switchRouteNoop
+	case "/service889/user/profile":
+		return switchRouteNoop
+	case "/service890/user/profile":
+		return switchRouteNoop
+	case "/service891/user/profile":
+		return switchRouteNoop
+	case "/service892/user/profile":
+		return switchRouteNoop
+	case "/service893/user/profile":
+		return switchRouteNoop
+	case "/service894/user/profile":
+		return switchRouteNoop
+	case "/service895/user/profile":
+		return switchRouteNoop
+	case "/service896/user/profile":
+		return switchRouteNoop
+	case "/service897/user/profile":
+		return switchRouteNoop
+	case "/service898/user/profile":
+		return switchRouteNoop
+	case "/service899/user/profile":
+		return switchRouteNoop
+	case "/service900/user/profile":
+		return switchRouteNoop
+	case "/service901/user/profile":
+		return switchRouteNoop
+	case "/service902/user/profile":
+		return switchRouteNoop
+	case "/service903/user/profile":
+		return switchRouteNoop
+	case "/service904/user/profile":
+		return switchRouteNoop
+	case "/service905/user/profile":
+		return switchRouteNoop
+	case "/service906/user/profile":
+		return switchRouteNoop
+	case "/service907/user/profile":
+		return switchRouteNoop
+	case "/service908/user/profile":
+		return switchRouteNoop
+	case "/service909/user/profile":
+		return switchRouteNoop
+	case "/service910/user/profile":
+		return switchRouteNoop
+	case "/service911/user/profile":
+		return switchRouteNoop
+	case "/service912/user/profile":
+		return switchRouteNoop
+	case "/service913/user/profile":
+		return switchRouteNoop
+	case "/service914/user/profile":
+		return switchRouteNoop
+	case "/service915/user/profile":
+		return switchRouteNoop
+	case "/service916/user/profile":
+		return switchRouteNoop
+	case "/service917/user/profile":
+		return switchRouteNoop
+	case "/service918/user/profile":
+		return switchRouteNoop
+	case "/service919/user/profile":
+		return switchRouteNoop
+	case "/service920/user/profile":
+		return switchRouteNoop
+	case "/service921/user/profile":
+		return switchRouteNoop
+	case "/service922/user/profile":
+		return switchRouteNoop
+	case "/service923/user/profile":
+		return switchRouteNoop
+	case "/service924/user/profile":
+		return switchRouteNoop
+	case "/service925/user/profile":
+		return switchRouteNoop
+	case "/service926/user/profile":
+		return switchRouteNoop
+	case "/service927/user/profile":
+		return switchRouteNoop
+	case "/service928/user/profile":
+		return switchRouteNoop
+	case "/service929/user/profile":
+		return switchRouteNoop
+	case "/service930/user/profile":
+		return switchRouteNoop
+	case "/service931/user/profile":
+		return switchRouteNoop
+	case "/service932/user/profile":
+		return switchRouteNoop
+	case "/service933/user/profile":
+		return switchRouteNoop
+	case "/service934/user/profile":
+		return switchRouteNoop
+	case "/service935/user/profile":
+		return switchRouteNoop
+	case "/service936/user/profile":
+		return switchRouteNoop
+	case "/service937/user/profile":
+		return switchRouteNoop
+	case "/service938/user/profile":
+		return switchRouteNoop
+	case "/service939/user/profile":
+		return switchRouteNoop
+	case "/service940/user/profile":
+		return switchRouteNoop
+	case "/service941/user/profile":
+		return switchRouteNoop
+	case "/service942/user/profile":
+		return switchRouteNoop
+	case "/service943/user/profile":
+		return switchRouteNoop
+	case "/service944/user/profile":
+		return switchRouteNoop
+	case "/service945/user/profile":
+		return switchRouteNoop
+	case "/service946/user/profile":
+		return switchRouteNoop
+	case "/service947/user/profile":
+		return switchRouteNoop
+	case "/service948/user/profile":
+		return switchRouteNoop
+	case "/service949/user/profile":
+		return switchRouteNoop
+	case "/service950/user/profile":
+		return switchRouteNoop
+	case "/service951/user/profile":
+		return switchRouteNoop
+	case "/service952/user/profile":
+		return switchRouteNoop
+	case "/service953/user/profile":
+		return switchRouteNoop
+	case "/service954/user/profile":
+		return switchRouteNoop
+	case "/service955/user/profile":
+		return switchRouteNoop
+	case "/service956/user/profile":
+		return switchRouteNoop
+	case "/service957/user/profile":
+		return switchRouteNoop
+	case "/service958/user/profile":
+		return switchRouteNoop
+	case "/service959/user/profile":
+		return switchRouteNoop
+	case "/service960/user/profile":
+		return switchRouteNoop
+	case "/service961/user/profile":
+		return switchRouteNoop
+	case "/service962/user/profile":
+		return switchRouteNoop
+	case "/service963/user/profile":
+		return switchRouteNoop
+	case "/service964/user/profile":
+		return switchRouteNoop
+	case "/service965/user/profile":
+		return switchRouteNoop
+	case "/service966/user/profile":
+		return switchRouteNoop
+	case "/service967/user/profile":
+		return switchRouteNoop
+	case "/service968/user/profile":
+		return switchRouteNoop
+	case "/service969/user/profile":
+		return switchRouteNoop
+	case "/service970/user/profile":
+		return switchRouteNoop
+	case "/service971/user/profile":
+		return switchRouteNoop
+	case "/service972/user/profile":
+		return switchRouteNoop
+	case "/service973/user/profile":
+		return switchRouteNoop
+	case "/service974/user/profile":
+		return switchRouteNoop
+	case "/service975/user/profile":
+		return switchRouteNoop
+	case "/service976/user/profile":
+		return switchRouteNoop
+	case "/service977/user/profile":
+		return switchRouteNoop
+	case "/service978/user/profile":
+		return switchRouteNoop
+	case "/service979/user/profile":
+		return switchRouteNoop
+	case "/service980/user/profile":
+		return switchRouteNoop
+	case "/service981/user/profile":
+		return switchRouteNoop
+	case "/service982/user/profile":
+		return switchRouteNoop
+	case "/service983/user/profile":
+		return switchRouteNoop
+	case "/service984/user/profile":
+		return switchRouteNoop
+	case "/service985/user/profile":
+		return switchRouteNoop
+	case "/service986/user/profile":
+		return switchRouteNoop
+	case "/service987/user/profile":
+		return switchRouteNoop
+	case "/service988/user/profile":
+		return switchRouteNoop
+	case "/service989/user/profile":
+		return switchRouteNoop
+	case "/service990/user/profile":
+		return switchRouteNoop
+	case "/service991/user/profile":
+		return switchRouteNoop
+	case "/service992/user/profile":
+		return switchRouteNoop
+	case "/service993/user/profile":
+		return switchRouteNoop
+	case "/service994/user/profile":
+		return switchRouteNoop
+	case "/service995/user/profile":
+		return switchRouteNoop
+	case "/service996/user/profile":
+		return switchRouteNoop
+	case "/service997/user/profile":
+		return switchRouteNoop
+	case "/service998/user/profile":
+		return switchRouteNoop
+	case "/service999/user/profile":
+		return switchRouteNoop
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testCreatedUser struct {
+	ID      uint64 `json:"id"`
+	Deleted bool   `json:"-"`
+}
+
+func (u *testCreatedUser) StatusCode() int {
+	if u.Deleted {
+		return http.StatusNoContent
+	}
+	return http.StatusCreated
+}
+
+func (u *testCreatedUser) Headers() http.Header {
+	return http.Header{"Location": {"/user/42"}}
+}
+
+func TestWriteResult(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, ApiUserCreate, nil)
+
+	cases := []struct {
+		Status   int
+		Result   *testCreatedUser
+		Code     int
+		Location string
+		Body     string
+	}{
+		{http.StatusOK, &testCreatedUser{ID: 42}, http.StatusCreated, "/user/42", `{"response":{"id":42},"error":""}` + "\n"},
+		{http.StatusOK, &testCreatedUser{ID: 42, Deleted: true}, http.StatusNoContent, "/user/42", ""},
+		{http.StatusAccepted, nil, http.StatusAccepted, "", `{"response":null,"error":""}` + "\n"},
+		{http.StatusNoContent, nil, http.StatusNoContent, "", ""},
+	}
+
+	for i, item := range cases {
+		w := httptest.NewRecorder()
+		writeResult(w, r, item.Status, item.Result, response{Response: item.Result})
+
+		if w.Code != item.Code || w.Header().Get("Location") != item.Location || w.Body.String() != item.Body {
+			t.Errorf("[%d] expected %d %q %s, got %d %q %s", i, item.Code, item.Location, item.Body,
+				w.Code, w.Header().Get("Location"), w.Body)
+		}
+	}
+}
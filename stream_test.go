@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestApiStream(t *testing.T) {
+	users := benchmarkUsers(2)
+	r := httptest.NewRequest(http.MethodGet, "/feed", nil)
+
+	w := httptest.NewRecorder()
+	stream := newApiStream(w, false)
+	for _, user := range users {
+		if err := stream.send(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream.fail(r, http.StatusInternalServerError, "internal error")
+
+	expected := `{"id":0,"login":"user0","full_name":"Vasily Romanov","status":10}` + "\n" +
+		`{"id":1,"login":"user1","full_name":"Vasily Romanov","status":10}` + "\n" +
+		`{"error":"internal error"}` + "\n"
+	if w.Body.String() != expected || w.Header().Get("Content-Type") != "application/x-ndjson" || !w.Flushed {
+		t.Errorf("unexpected ndjson stream %s %s", w.Header().Get("Content-Type"), w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	stream = newApiStream(w, true)
+	stream.send(users[0])
+	stream.fail(r, http.StatusNotFound, "user not exist")
+
+	expected = `data: {"id":0,"login":"user0","full_name":"Vasily Romanov","status":10}` + "\n\n" +
+		"event: error\n" + `data: {"error":"user not exist"}` + "\n\n"
+	if w.Body.String() != expected || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("unexpected sse stream %s %s", w.Header().Get("Content-Type"), w.Body)
+	}
+
+	// до первого элемента ошибка отдаётся обычным ответом
+	w = httptest.NewRecorder()
+	newApiStream(w, true).fail(r, http.StatusNotFound, "user not exist")
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"error":"user not exist"}`+"\n" {
+		t.Errorf("unexpected error before stream %d %s", w.Code, w.Body)
+	}
+}
+
+func TestApiStreamFlush(t *testing.T) {
+	items := make(chan *User)
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		stream := newApiStream(w, false)
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case item := <-items:
+				stream.send(item)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	// заголовки уходят вместе с первым элементом
+	go func() { items <- benchmarkUsers(1)[0] }()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// элемент приходит до закрытия ответа, значит он был сброшен клиенту
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != `{"id":0,"login":"user0","full_name":"Vasily Romanov","status":10}`+"\n" {
+		t.Errorf("unexpected line %q %v", line, err)
+	}
+
+	cancel()
+	resp.Body.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("handler is not stopped by cancelled request")
+	}
+}
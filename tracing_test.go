@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	tracer := &RecordingTracer{}
+	router := NewApiRouter(NewMyApi(), NewOtherApi())
+	router.Config = NewApiConfig()
+	router.Config.Tracer = tracer
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/my"+ApiUserCreate,
+		strings.NewReader("login=mr.moderator&age=32&status=moderator&full_name=Ivan_Ivanov"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Auth", "100500")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// фазы заканчиваются раньше запроса, корневой спан последний
+	spans := tracer.Spans()
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	if strings.Join(names, ",") != "auth,validate,call,encode,MyApi.Create" {
+		t.Fatalf("unexpected spans %v", names)
+	}
+
+	root := spans[len(spans)-1]
+	for _, span := range spans[:len(spans)-1] {
+		if span.ParentID != root.ID || span.Err != nil {
+			t.Errorf("unexpected phase span %+v", span)
+		}
+	}
+	for key, value := range map[string]any{
+		"apigen.receiver":  "MyApi",
+		"http.method":      http.MethodPost,
+		"http.route":       ApiUserCreate,
+		"apigen.auth":      true,
+		"http.status_code": http.StatusOK,
+	} {
+		if attr, _ := root.Attr(key); attr != value {
+			t.Errorf("%s: expected %v, got %v", key, value, attr)
+		}
+	}
+
+	// bad_user падает в методе, ошибка попадает в спаны call и запроса
+	tracer.Reset()
+	resp, err = http.Get(ts.URL + "/my" + ApiUserProfile + "?login=bad_user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	spans = tracer.Spans()
+	if len(spans) != 3 || spans[1].Name != "call" || spans[1].Err == nil || spans[2].Err == nil {
+		t.Errorf("unexpected spans of failed request %+v", spans)
+	}
+
+	// невалидный запрос закрывает спан validate отложенным вызовом
+	tracer.Reset()
+	req, _ = http.NewRequest(http.MethodPost, ts.URL+"/my"+ApiUserCreate, strings.NewReader(url.Values{"login": {"short"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Auth", "100500")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	spans = tracer.Spans()
+	if len(spans) != 3 || spans[1].Name != "validate" || !errors.Is(spans[1].Err, ErrRequestRejected) || spans[2].Err != nil {
+		t.Errorf("unexpected spans of rejected request %+v", spans)
+	}
+}